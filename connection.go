@@ -5,27 +5,46 @@ package pxmysql
 import (
 	"context"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 
+	"github.com/golistic/pxmysql/decimal"
 	"github.com/golistic/pxmysql/xmysql"
 )
 
 type connection struct {
 	cfg     *xmysql.ConnectConfig
 	session *xmysql.Session
+
+	// interpolateParams makes queries with arguments substitute placeholders
+	// client-side instead of using server-side prepared statements.
+	interpolateParams bool
+	// stmtCache caches prepared statements used by ExecContext and QueryContext;
+	// it is nil when disabled.
+	stmtCache *stmtCache
+	// resetSession makes ResetSession reset the session on the server.
+	resetSession bool
 }
 
 var (
-	_ driver.Conn           = (*connection)(nil)
-	_ driver.ConnBeginTx    = (*connection)(nil)
-	_ driver.Pinger         = (*connection)(nil)
-	_ driver.ExecerContext  = (*connection)(nil)
-	_ driver.QueryerContext = (*connection)(nil)
+	_ driver.Conn               = (*connection)(nil)
+	_ driver.ConnBeginTx        = (*connection)(nil)
+	_ driver.ConnPrepareContext = (*connection)(nil)
+	_ driver.Pinger             = (*connection)(nil)
+	_ driver.ExecerContext      = (*connection)(nil)
+	_ driver.QueryerContext     = (*connection)(nil)
+	_ driver.NamedValueChecker  = (*connection)(nil)
+	_ driver.SessionResetter    = (*connection)(nil)
 )
 
 func (c *connection) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
 
-	prep, err := c.session.PrepareStatement(context.Background(), query)
+func (c *connection) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	prep, err := c.session.PrepareStatement(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +70,7 @@ func (c *connection) Begin() (driver.Tx, error) {
 func (c *connection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
 	q := "START TRANSACTION"
 	if opts.ReadOnly {
-		q += q + " READ ONLY"
+		q += " READ ONLY"
 	}
 
 	if _, err := c.session.ExecuteStatement(ctx, q); err != nil {
@@ -61,6 +80,25 @@ func (c *connection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver
 	return &Transaction{session: c.session}, nil
 }
 
+// ResetSession is called by database/sql before the connection is reused. When
+// the data source has ResetSession set, the session is reset on the server,
+// which also clears the cache of prepared statements.
+func (c *connection) ResetSession(ctx context.Context) error {
+	if c.session == nil {
+		return driver.ErrBadConn
+	}
+
+	if !c.resetSession {
+		return nil
+	}
+
+	if err := c.session.Reset(ctx); err != nil {
+		return fmt.Errorf("%s (%w)", err.Error(), driver.ErrBadConn)
+	}
+
+	return nil
+}
+
 func (c *connection) Ping(ctx context.Context) error {
 	if c.session == nil {
 		return fmt.Errorf("not connected (%w)", driver.ErrBadConn)
@@ -73,32 +111,171 @@ func (c *connection) Ping(ctx context.Context) error {
 	return nil
 }
 
+// ExecContext executes query using a server-side prepared statement, unless
+// placeholders are substituted client-side. Statements which cannot be
+// prepared, such as USE, are executed as is and are not cached.
 func (c *connection) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
-	prep, err := c.session.PrepareStatement(context.Background(), query)
+	if c.interpolate() || !c.session.Preparable(query) {
+		res, err := c.session.ExecuteStatement(ctx, query, namedValuesToAny(args)...)
+		if err != nil {
+			return nil, handleError(err)
+		}
+		return &result{xpresult: res}, nil
+	}
+
+	prep, args, release, err := c.prepare(ctx, query, args)
 	if err != nil {
 		return nil, handleError(err)
 	}
 
-	defer func() { _ = prep.Deallocate(ctx) }()
-
 	stmt := &statement{
 		prepared: prep,
 	}
 
-	return stmt.ExecContext(ctx, args)
+	res, err := stmt.ExecContext(ctx, args)
+	release(ctx, err)
+
+	return res, err
 }
 
 func (c *connection) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
-	prep, err := c.session.PrepareStatement(context.Background(), query)
+	if c.interpolate() || !c.session.Preparable(query) {
+		res, err := c.session.ExecuteStatement(ctx, query, namedValuesToAny(args)...)
+		if err != nil {
+			return nil, handleError(err)
+		}
+		return &rows{xpresult: res}, nil
+	}
+
+	prep, args, release, err := c.prepare(ctx, query, args)
 	if err != nil {
 		return nil, handleError(err)
 	}
 
-	defer func() { _ = prep.Deallocate(ctx) }()
-
 	stmt := &statement{
 		prepared: prep,
 	}
 
-	return stmt.QueryContext(ctx, args)
+	rows, err := stmt.QueryContext(ctx, args)
+	release(ctx, err)
+
+	return rows, err
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface. Values
+// which the X Protocol can handle directly, such as decimal.Decimal, []string
+// (MySQL SET) and types implementing driver.Valuer (like the null types), are
+// passed through without conversion. Other values are converted by database/sql.
+func (c *connection) CheckNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case decimal.Decimal, *decimal.Decimal, []string, driver.Valuer, json.Marshaler:
+		return nil
+	}
+
+	if rv := reflect.ValueOf(nv.Value); c.cfg.ExpandSlices && rv.Kind() == reflect.Slice {
+		return nil // expanded when executing
+	}
+
+	return driver.ErrSkip
+}
+
+// interpolate returns whether queries are executed substituting the placeholders
+// client-side. When the character set of the session cannot be escaped safely,
+// server-side prepared statements are used.
+func (c *connection) interpolate() bool {
+	return c.interpolateParams && c.session.InterpolationSafe()
+}
+
+// prepare prepares query to be executed with args. When the configuration has
+// ExpandSlices set, slices within args are expanded, and the returned arguments
+// replace args.
+// When the connection caches prepared statements, a cached one is returned
+// if available. The returned function must be called with the error, if any,
+// of executing the prepared statement; it deallocates the statement unless it
+// is cached. Cached statements which failed to execute are removed from the
+// cache, since their server-side handle might be gone.
+func (c *connection) prepare(ctx context.Context, query string,
+	args []driver.NamedValue) (*xmysql.Prepared, []driver.NamedValue, func(context.Context, error), error) {
+
+	names := argNames(args)
+
+	if c.cfg.ExpandSlices {
+		var expanded []any
+		var err error
+		if query, expanded, err = c.session.ExpandSlices(query, namedValuesToAny(args)...); err != nil {
+			return nil, nil, nil, err
+		}
+
+		names = nil
+		args = make([]driver.NamedValue, len(expanded))
+		for i, v := range expanded {
+			args[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+		}
+	}
+
+	// @name is only a placeholder when such argument is given; statements
+	// prepared using another SQL mode or schema are not reused
+	key := c.session.SQLMode() + "\x00" + c.session.ActiveSchemaName() + "\x00" + query
+	if len(names) > 0 {
+		key += "\x00" + strings.Join(names, ",")
+	}
+
+	if c.stmtCache != nil {
+		if prep, ok := c.stmtCache.get(key, c.session.Epoch()); ok {
+			return prep, args, c.releaseCached(key), nil
+		}
+	}
+
+	prep, err := c.session.PrepareNamed(ctx, query, names...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if c.stmtCache != nil {
+		c.stmtCache.put(key, prep)
+		return prep, args, c.releaseCached(key), nil
+	}
+
+	return prep, args, func(ctx context.Context, _ error) { _ = prep.Deallocate(ctx) }, nil
+}
+
+// releaseCached returns the function releasing the prepared statement cached
+// using key; it is removed from the cache when executing it failed.
+func (c *connection) releaseCached(key string) func(context.Context, error) {
+	return func(_ context.Context, err error) {
+		if err != nil {
+			c.stmtCache.remove(key)
+		}
+	}
+}
+
+// StmtCacheStats returns statistics of the cache of prepared statements of the
+// connection. The connection can be retrieved using sql.Conn.Raw and asserting
+// the interface `interface{ StmtCacheStats() pxmysql.StmtCacheStats }`.
+// When caching is disabled, the zero value is returned.
+func (c *connection) StmtCacheStats() StmtCacheStats {
+	if c.stmtCache == nil {
+		return StmtCacheStats{}
+	}
+	return c.stmtCache.stats()
+}
+
+// namedValuesToAny returns args as slice of any.
+func namedValuesToAny(args []driver.NamedValue) []any {
+	values := make([]any, len(args))
+	for i, a := range args {
+		values[i] = a
+	}
+	return values
+}
+
+// argNames returns the names of the named arguments within args.
+func argNames(args []driver.NamedValue) []string {
+	var names []string
+	for _, a := range args {
+		if a.Name != "" {
+			names = append(names, a.Name)
+		}
+	}
+	return names
 }
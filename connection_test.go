@@ -5,6 +5,7 @@ package pxmysql_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -12,7 +13,9 @@ import (
 
 	"github.com/golistic/xgo/xt"
 
+	"github.com/golistic/pxmysql/decimal"
 	"github.com/golistic/pxmysql/mysqlerrors"
+	"github.com/golistic/pxmysql/null"
 )
 
 func TestConnection_Begin(t *testing.T) {
@@ -142,6 +145,38 @@ func TestConnection_QueryContext(t *testing.T) {
 		xt.KO(t, err)
 		xt.Assert(t, errors.Is(err, mysqlerrors.ErrContextDeadlineExceeded), err.Error())
 	})
+
+	t.Run("custom argument types", func(t *testing.T) {
+		type name string
+		type level int8
+
+		var cases = []struct {
+			arg any
+			exp string
+		}{
+			{arg: name("Gopher"), exp: "Gopher"},
+			{arg: level(-3), exp: "-3"},
+			{arg: null.String{String: "nullable", Valid: true}, exp: "nullable"},
+			{arg: null.New(*decimal.MustNew("1.50")), exp: "1.50"},
+			{arg: *decimal.MustNew("3.14"), exp: "3.14"},
+			{arg: []string{"a", "b"}, exp: "a,b"},
+			{arg: json.RawMessage(`{"x":1}`), exp: `{"x":1}`},
+		}
+
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%T", c.arg), func(t *testing.T) {
+				var got string
+				xt.OK(t, db.QueryRow("SELECT CAST(? AS CHAR)", c.arg).Scan(&got))
+				xt.Eq(t, c.exp, got)
+			})
+		}
+
+		t.Run("NULL", func(t *testing.T) {
+			var got *string
+			xt.OK(t, db.QueryRow("SELECT ?", null.String{}).Scan(&got))
+			xt.Assert(t, got == nil)
+		})
+	})
 }
 
 func TestConnector_Connect(t *testing.T) {
@@ -15,33 +15,51 @@ type connector struct {
 
 var _ driver.Connector = &connector{}
 
+// NewConnector returns a connector using ds, which is used with sql.OpenDB.
+// This allows configuration which cannot be given using the DSN, such as
+// DataSource.CredentialsProvider. Usually, ds is created using NewDataSource.
+func NewConnector(ds DataSource) driver.Connector {
+	return &connector{
+		dataSource: ds,
+	}
+}
+
 func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
 
 	// dataSource at this point is valid
-	config := &xmysql.ConnectConfig{
-		UseTLS:     c.dataSource.UseTLS,
-		AuthMethod: xmysql.AuthMethodAuto,
-		Username:   c.dataSource.User,
-		Schema:     c.dataSource.Schema,
+	config := c.dataSource.connectConfig()
+
+	ses, err := c.getSession(ctx, config)
+	if err != nil {
+		return nil, err
 	}
-	config.SetPassword(c.dataSource.Password)
 
-	switch c.dataSource.Protocol {
-	case "unix":
-		config.UnixSockAddr = c.dataSource.Address
-	case "tcp":
-		config.Address = c.dataSource.Address
+	cnx := &connection{
+		cfg:               config,
+		session:           ses,
+		interpolateParams: c.dataSource.InterpolateParams,
+		resetSession:      c.dataSource.ResetSession,
 	}
 
-	ses, err := xmysql.GetSession(ctx, config)
-	if err != nil {
-		return nil, err
+	if c.dataSource.StmtCacheSize > 0 {
+		cnx.stmtCache = newStmtCache(c.dataSource.StmtCacheSize)
+	}
+
+	return cnx, nil
+}
+
+// getSession opens a session using config. When the data source has fallback
+// addresses, these are tried in order after the address, unless hosts are
+// randomized. Hosts of the data source are already part of config.
+func (c connector) getSession(ctx context.Context, config *xmysql.ConnectConfig) (*xmysql.Session, error) {
+	if len(config.Hosts) == 0 && len(c.dataSource.FallbackAddresses) > 0 {
+		config.Hosts = []xmysql.Host{{Address: config.Address}}
+		for _, addr := range c.dataSource.FallbackAddresses {
+			config.Hosts = append(config.Hosts, xmysql.Host{Address: addr})
+		}
 	}
 
-	return &connection{
-		cfg:     config,
-		session: ses,
-	}, nil
+	return xmysql.GetSession(ctx, config)
 }
 
 func (c connector) Driver() driver.Driver {
@@ -3,10 +3,22 @@
 package pxmysql
 
 import (
+	"cmp"
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golistic/xgo/xconv"
 	"github.com/golistic/xgo/xsql"
+	"github.com/golistic/xgo/xstrings"
+
+	"github.com/golistic/pxmysql/xmysql"
 )
 
 // DataSource defines the configuration of the connection. It embeds xsql.DataSource
@@ -14,11 +26,134 @@ import (
 type DataSource struct {
 	xsql.DataSource
 
-	UseTLS bool
+	UseTLS            bool
+	ExpandSlices      bool
+	InterpolateParams bool
+	// StmtCacheSize is the number of prepared statements cached per
+	// connection; 0 disables caching.
+	StmtCacheSize int
+	// ResetSession makes connections reset the session each time they are
+	// reused from the pool (option resetSession), dropping server-side state
+	// such as user variables and prepared statements.
+	ResetSession bool
+
+	// AuthMethod is the authentication method (option authMethod); when empty,
+	// AUTO is used.
+	AuthMethod xmysql.AuthMethodType
+	// SSLMode defines whether and how TLS is used (option sslMode); when
+	// empty, UseTLS is used.
+	SSLMode xmysql.SSLMode
+	// TLSCA is the path to the CA certificate of the server (option tlsCA).
+	TLSCA string
+	// TLSSystemCAs makes the system's CAs trusted in addition to TLSCA
+	// (option tlsSystemCAs).
+	TLSSystemCAs bool
+	// TLSCert and TLSKey are the paths to the client certificate and its key
+	// (options tlsCert and tlsKey).
+	TLSCert string
+	TLSKey  string
+	// TLSCRL is the path to the certificate revocation list (option tlsCRL).
+	TLSCRL string
+	// TLSMinVersion and TLSMaxVersion limit the TLS versions, for example,
+	// TLSv1.3 (options tlsMinVersion and tlsMaxVersion).
+	TLSMinVersion uint16
+	TLSMaxVersion uint16
+	// TLSCipherSuites are the enabled cipher suites given as comma separated
+	// list of IANA names (option tlsCipherSuites).
+	TLSCipherSuites []uint16
+	// TimeZone is the name of the time location of the session (option timeZone).
+	TimeZone string
+	// Collation is the name of the collation of the connection (option collation).
+	Collation string
+
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+
+	// MaxAllowedPacket is the maximum size in bytes of messages sent to
+	// the server (option maxAllowedPacket).
+	MaxAllowedPacket int
+
+	// ConnectionAttributes are given as a comma separated list of key:value
+	// pairs (option connectionAttributes). They are sent to the server together
+	// with the default attributes. The value false disables sending any
+	// connection attributes.
+	ConnectionAttributes        map[string]string
+	DisableConnectionAttributes bool
+
+	// Compression is one of disabled, preferred, or required (option compression).
+	Compression string
+	// CompressionAlgorithms are the compression algorithms in order of preference
+	// (option compressionAlgorithms, comma separated).
+	CompressionAlgorithms []string
+
+	// FallbackAddresses are tried in order when the connection using the
+	// address fails (option fallbackAddresses, comma separated). Only
+	// supported with the tcp protocol.
+	FallbackAddresses []string
+	// Hosts are the servers tried in order of their priority instead of the
+	// address (option hosts, in the form read by xmysql.ParseHosts). They
+	// cannot be used together with FallbackAddresses, and are only supported
+	// with the tcp protocol.
+	Hosts []xmysql.Host
+	// RandomizeHosts makes hosts with equal priority, including the address
+	// and fallback addresses, tried in random order (option randomizeHosts).
+	RandomizeHosts bool
+
+	// EnvPrefix is the prefix of the environment variables overriding the
+	// configuration of the DSN (option envPrefix), for example, PXMYSQL for
+	// PXMYSQL_USER. See xmysql.ConnectConfig.ApplyEnv.
+	EnvPrefix string
+
+	// CredentialsProvider is called each time a connection is opened, and the
+	// returned user and password are used instead of User and Password. It
+	// cannot be given using the DSN; use NewConnector together with sql.OpenDB.
+	CredentialsProvider func(ctx context.Context) (user, password string, err error)
+}
+
+// dsnOptions are the names of the options supported within the DSN.
+var dsnOptions = []string{
+	"authMethod",
+	"collation",
+	"compression",
+	"compressionAlgorithms",
+	"connectTimeout",
+	"connectionAttributes",
+	"envPrefix",
+	"expandSlices",
+	"fallbackAddresses",
+	"hosts",
+	"interpolateParams",
+	"maxAllowedPacket",
+	"randomizeHosts",
+	"readTimeout",
+	"resetSession",
+	"sslMode",
+	"stmtCacheSize",
+	"timeZone",
+	"tlsCA",
+	"tlsCRL",
+	"tlsCert",
+	"tlsCipherSuites",
+	"tlsKey",
+	"tlsMaxVersion",
+	"tlsMinVersion",
+	"tlsSystemCAs",
+	"useTLS",
+	"writeTimeout",
 }
 
+// compressionModes are the valid values for the compression option.
+var compressionModes = []string{"disabled", "preferred", "required"}
+
 // NewDataSource instantiates a DataSource using the Data Source Name (DSN).
+// The name can also be a URI using the mysqlx:// scheme as used by MySQL Shell
+// (see xmysql.ParseURI).
 func NewDataSource(name string) (DataSource, error) {
+	if strings.HasPrefix(name, xmysql.URIScheme+"://") {
+		return newDataSourceFromURI(name)
+	}
+
 	xds, err := xsql.ParseDSN(name)
 	if err != nil {
 		return DataSource{}, err
@@ -33,6 +168,35 @@ func NewDataSource(name string) (DataSource, error) {
 		return DataSource{}, err
 	}
 
+	if ds.EnvPrefix != "" {
+		config := ds.connectConfig()
+		if err := config.ApplyEnv(ds.EnvPrefix); err != nil {
+			return DataSource{}, err
+		}
+		ds.setConnectConfig(config)
+	}
+
+	if err := ds.CheckValidity(); err != nil {
+		return DataSource{}, fmt.Errorf("configuration not valid (%w)", err)
+	}
+
+	return ds, nil
+}
+
+// newDataSourceFromURI instantiates a DataSource using the mysqlx:// URI.
+func newDataSourceFromURI(uri string) (DataSource, error) {
+	config, err := xmysql.ParseURI(uri)
+	if err != nil {
+		return DataSource{}, err
+	}
+
+	ds := DataSource{
+		DataSource: xsql.DataSource{
+			Options: url.Values{},
+		},
+	}
+	ds.setConnectConfig(config)
+
 	if err := ds.CheckValidity(); err != nil {
 		return DataSource{}, fmt.Errorf("configuration not valid (%w)", err)
 	}
@@ -40,6 +204,63 @@ func NewDataSource(name string) (DataSource, error) {
 	return ds, nil
 }
 
+// setConnectConfig sets the fields of ds using config, which is the reverse
+// of connectConfig. Fields specific to the driver are kept.
+func (ds *DataSource) setConnectConfig(config *xmysql.ConnectConfig) {
+	ds.User = config.Username
+	ds.Password = ""
+	if config.Password != nil {
+		ds.Password = *config.Password
+	}
+	ds.Schema = config.Schema
+
+	ds.Protocol = "tcp"
+	ds.Address = config.Address
+	if config.UnixSockAddr != "" {
+		ds.Protocol = "unix"
+		ds.Address = config.UnixSockAddr
+	}
+	if len(config.Hosts) > 0 {
+		// the address is the host tried first, but it is not used to connect
+		ds.Hosts = slices.Clone(config.Hosts)
+		ds.Address = slices.MaxFunc(ds.Hosts, func(a, b xmysql.Host) int {
+			return cmp.Compare(a.Priority, b.Priority)
+		}).Address
+		ds.FallbackAddresses = nil
+	}
+	ds.RandomizeHosts = config.RandomizeHosts
+	if ds.Address == "" {
+		ds.Address = xmysql.DefaultHost
+	}
+
+	ds.AuthMethod = ""
+	if config.AuthMethod != xmysql.AuthMethodAuto {
+		ds.AuthMethod = config.AuthMethod
+	}
+
+	ds.UseTLS = config.UseTLS
+	ds.SSLMode = config.SSLMode
+	ds.ExpandSlices = config.ExpandSlices
+	ds.TLSCA = config.TLSServerCACertPath
+	ds.TLSSystemCAs = config.TLSUseSystemCAs
+	ds.TLSCert = config.TLSClientCertPath
+	ds.TLSKey = config.TLSClientKeyPath
+	ds.TLSCRL = config.TLSCRLPath
+	ds.TLSMinVersion = config.TLSMinVersion
+	ds.TLSMaxVersion = config.TLSMaxVersion
+	ds.TLSCipherSuites = config.TLSCipherSuites
+	ds.TimeZone = config.TimeZoneName
+	ds.Collation = config.Collation
+	ds.ConnectTimeout = config.ConnectTimeout
+	ds.ReadTimeout = config.ReadTimeout
+	ds.WriteTimeout = config.WriteTimeout
+	ds.MaxAllowedPacket = config.MaxAllowedPacket
+	ds.ConnectionAttributes = config.ConnectionAttributes
+	ds.DisableConnectionAttributes = config.DisableConnectionAttributes
+	ds.Compression = strings.ToLower(string(config.Compression))
+	ds.CompressionAlgorithms = config.CompressionAlgorithms
+}
+
 // CheckValidity returns whether the DataSource has enough configuration to establish
 // a connection. Needed are the address, protocol, and username.
 func (ds *DataSource) CheckValidity() error {
@@ -50,12 +271,162 @@ func (ds *DataSource) CheckValidity() error {
 		return fmt.Errorf("user missing")
 	case ds.Protocol == "":
 		return fmt.Errorf("protocol missing")
+	case len(ds.FallbackAddresses) > 0 && ds.Protocol != "tcp":
+		return fmt.Errorf("fallback addresses only supported with protocol tcp")
+	case len(ds.Hosts) > 0 && ds.Protocol != "tcp":
+		return fmt.Errorf("hosts only supported with protocol tcp")
+	case len(ds.Hosts) > 0 && len(ds.FallbackAddresses) > 0:
+		return fmt.Errorf("hosts and fallback addresses cannot be used together")
 	default:
 		return nil
 	}
 }
 
+// connectConfig returns the configuration for connecting to the MySQL server
+// using ds.
+func (ds *DataSource) connectConfig() *xmysql.ConnectConfig {
+	config := &xmysql.ConnectConfig{
+		UseTLS:              ds.UseTLS,
+		SSLMode:             ds.SSLMode,
+		AuthMethod:          xmysql.AuthMethodAuto,
+		Username:            ds.User,
+		Schema:              ds.Schema,
+		ExpandSlices:        ds.ExpandSlices,
+		TLSServerCACertPath: ds.TLSCA,
+		TLSUseSystemCAs:     ds.TLSSystemCAs,
+		TLSClientCertPath:   ds.TLSCert,
+		TLSClientKeyPath:    ds.TLSKey,
+		TLSCRLPath:          ds.TLSCRL,
+		TLSMinVersion:       ds.TLSMinVersion,
+		TLSMaxVersion:       ds.TLSMaxVersion,
+		TLSCipherSuites:     ds.TLSCipherSuites,
+		TimeZoneName:        ds.TimeZone,
+		Collation:           ds.Collation,
+		ConnectTimeout:      ds.ConnectTimeout,
+		ReadTimeout:         ds.ReadTimeout,
+		WriteTimeout:        ds.WriteTimeout,
+		MaxAllowedPacket:    ds.MaxAllowedPacket,
+
+		ConnectionAttributes:        ds.ConnectionAttributes,
+		DisableConnectionAttributes: ds.DisableConnectionAttributes,
+		Compression:                 xmysql.CompressionMode(strings.ToUpper(ds.Compression)),
+		CompressionAlgorithms:       ds.CompressionAlgorithms,
+		CredentialsProvider:         ds.CredentialsProvider,
+		RandomizeHosts:              ds.RandomizeHosts,
+	}
+	config.SetPassword(ds.Password)
+
+	if ds.AuthMethod != "" {
+		config.AuthMethod = ds.AuthMethod
+	}
+
+	switch ds.Protocol {
+	case "unix":
+		config.UnixSockAddr = ds.Address
+	case "tcp":
+		config.Address = ds.Address
+	}
+
+	if len(ds.Hosts) > 0 {
+		config.Hosts = slices.Clone(ds.Hosts)
+	}
+
+	return config
+}
+
+// FormatDSN returns the Data Source Name of ds including the password. Options
+// are taken from the fields of ds, and only those not having their default
+// value are included. Using the result with NewDataSource returns the
+// same DataSource.
+func (ds *DataSource) FormatDSN() string {
+	options := url.Values{}
+
+	setBool := func(name string, v bool) {
+		if v {
+			options.Set(name, "true")
+		}
+	}
+	setString := func(name string, v string) {
+		if v != "" {
+			options.Set(name, v)
+		}
+	}
+	setInt := func(name string, v int) {
+		if v != 0 {
+			options.Set(name, strconv.Itoa(v))
+		}
+	}
+	setDuration := func(name string, v time.Duration) {
+		if v != 0 {
+			options.Set(name, v.String())
+		}
+	}
+
+	setBool("useTLS", ds.UseTLS)
+	setBool("expandSlices", ds.ExpandSlices)
+	setBool("interpolateParams", ds.InterpolateParams)
+	setInt("stmtCacheSize", ds.StmtCacheSize)
+	setBool("resetSession", ds.ResetSession)
+	setString("sslMode", string(ds.SSLMode))
+	setString("authMethod", string(ds.AuthMethod))
+	setString("tlsCA", ds.TLSCA)
+	setBool("tlsSystemCAs", ds.TLSSystemCAs)
+	setString("tlsCert", ds.TLSCert)
+	setString("tlsKey", ds.TLSKey)
+	setString("tlsCRL", ds.TLSCRL)
+	if ds.TLSMinVersion != 0 {
+		options.Set("tlsMinVersion", xmysql.TLSVersionName(ds.TLSMinVersion))
+	}
+	if ds.TLSMaxVersion != 0 {
+		options.Set("tlsMaxVersion", xmysql.TLSVersionName(ds.TLSMaxVersion))
+	}
+	if len(ds.TLSCipherSuites) > 0 {
+		var names []string
+		for _, id := range ds.TLSCipherSuites {
+			names = append(names, tls.CipherSuiteName(id))
+		}
+		options.Set("tlsCipherSuites", strings.Join(names, ","))
+	}
+	setString("timeZone", ds.TimeZone)
+	setString("collation", ds.Collation)
+	setDuration("connectTimeout", ds.ConnectTimeout)
+	setDuration("readTimeout", ds.ReadTimeout)
+	setDuration("writeTimeout", ds.WriteTimeout)
+	setInt("maxAllowedPacket", ds.MaxAllowedPacket)
+	setString("compression", ds.Compression)
+	setString("compressionAlgorithms", strings.Join(ds.CompressionAlgorithms, ","))
+	setString("fallbackAddresses", strings.Join(ds.FallbackAddresses, ","))
+	setString("hosts", xmysql.FormatHosts(ds.Hosts))
+	setBool("randomizeHosts", ds.RandomizeHosts)
+	setString("envPrefix", ds.EnvPrefix)
+
+	if ds.DisableConnectionAttributes {
+		options.Set("connectionAttributes", "false")
+	} else if len(ds.ConnectionAttributes) > 0 {
+		var attrs []string
+		for k, v := range ds.ConnectionAttributes {
+			attrs = append(attrs, k+":"+v)
+		}
+		sort.Strings(attrs)
+		options.Set("connectionAttributes", strings.Join(attrs, ","))
+	}
+
+	xds := ds.DataSource
+	xds.Options = options
+
+	return xds.Format()
+}
+
 func (ds *DataSource) handleOptions() error {
+	for name, values := range ds.Options {
+		if !xstrings.SliceHas(dsnOptions, name) {
+			return fmt.Errorf("unknown option '%s'", name)
+		}
+		if len(values) > 1 {
+			return fmt.Errorf("option %s given more than once", name)
+		}
+	}
+
 	var err error
 	useTLS := ds.Options.Get("useTLS")
 	if useTLS != "" {
@@ -65,5 +436,214 @@ func (ds *DataSource) handleOptions() error {
 		}
 	}
 
+	expandSlices := ds.Options.Get("expandSlices")
+	if expandSlices != "" {
+		ds.ExpandSlices, err = xconv.ParseBool(expandSlices)
+		if err != nil {
+			return fmt.Errorf("invalid value for expandSlices option (was %s)", expandSlices)
+		}
+	}
+
+	interpolateParams := ds.Options.Get("interpolateParams")
+	if interpolateParams != "" {
+		ds.InterpolateParams, err = xconv.ParseBool(interpolateParams)
+		if err != nil {
+			return fmt.Errorf("invalid value for interpolateParams option (was %s)", interpolateParams)
+		}
+	}
+
+	stmtCacheSize := ds.Options.Get("stmtCacheSize")
+	if stmtCacheSize != "" {
+		ds.StmtCacheSize, err = strconv.Atoi(stmtCacheSize)
+		if err != nil || ds.StmtCacheSize < 0 {
+			return fmt.Errorf("invalid value for stmtCacheSize option (was %s)", stmtCacheSize)
+		}
+	}
+
+	resetSession := ds.Options.Get("resetSession")
+	if resetSession != "" {
+		ds.ResetSession, err = xconv.ParseBool(resetSession)
+		if err != nil {
+			return fmt.Errorf("invalid value for resetSession option (was %s)", resetSession)
+		}
+	}
+
+	sslMode := ds.Options.Get("sslMode")
+	if sslMode != "" {
+		if ds.SSLMode, err = xmysql.ParseSSLMode(sslMode); err != nil {
+			return fmt.Errorf("invalid value for sslMode option (was %s)", sslMode)
+		}
+	}
+
+	authMethod := ds.Options.Get("authMethod")
+	if authMethod != "" {
+		ds.AuthMethod = xmysql.AuthMethodType(strings.ToUpper(authMethod))
+		if !xmysql.SupportedAuthMethods().Has(ds.AuthMethod) {
+			return fmt.Errorf("invalid value for authMethod option (was %s)", authMethod)
+		}
+	}
+
+	ds.TLSCA = ds.Options.Get("tlsCA")
+
+	tlsSystemCAs := ds.Options.Get("tlsSystemCAs")
+	if tlsSystemCAs != "" {
+		ds.TLSSystemCAs, err = xconv.ParseBool(tlsSystemCAs)
+		if err != nil {
+			return fmt.Errorf("invalid value for tlsSystemCAs option (was %s)", tlsSystemCAs)
+		}
+	}
+
+	ds.TLSCert = ds.Options.Get("tlsCert")
+	ds.TLSKey = ds.Options.Get("tlsKey")
+	ds.TLSCRL = ds.Options.Get("tlsCRL")
+
+	if (ds.TLSCert == "") != (ds.TLSKey == "") {
+		return fmt.Errorf("options tlsCert and tlsKey must be used together")
+	}
+
+	if ds.TLSMinVersion, err = parseTLSVersionOption("tlsMinVersion", ds.Options.Get("tlsMinVersion")); err != nil {
+		return err
+	}
+	if ds.TLSMaxVersion, err = parseTLSVersionOption("tlsMaxVersion", ds.Options.Get("tlsMaxVersion")); err != nil {
+		return err
+	}
+
+	cipherSuites := ds.Options.Get("tlsCipherSuites")
+	if cipherSuites != "" {
+		for _, name := range strings.Split(cipherSuites, ",") {
+			id, err := xmysql.ParseTLSCipherSuite(strings.TrimSpace(name))
+			if err != nil {
+				return fmt.Errorf("invalid value for tlsCipherSuites option (was %s)", cipherSuites)
+			}
+			ds.TLSCipherSuites = append(ds.TLSCipherSuites, id)
+		}
+	}
+
+	timeZone := ds.Options.Get("timeZone")
+	if timeZone != "" {
+		if _, err := time.LoadLocation(timeZone); err != nil {
+			return fmt.Errorf("invalid value for timeZone option (was %s)", timeZone)
+		}
+		ds.TimeZone = timeZone
+	}
+
+	collation := ds.Options.Get("collation")
+	if collation != "" {
+		if !xmysql.IsSupportedCollation(collation) {
+			return fmt.Errorf("invalid value for collation option (was %s)", collation)
+		}
+		ds.Collation = collation
+	}
+
+	if ds.ConnectTimeout, err = parseDurationOption("connectTimeout", ds.Options.Get("connectTimeout")); err != nil {
+		return err
+	}
+	if ds.ReadTimeout, err = parseDurationOption("readTimeout", ds.Options.Get("readTimeout")); err != nil {
+		return err
+	}
+	if ds.WriteTimeout, err = parseDurationOption("writeTimeout", ds.Options.Get("writeTimeout")); err != nil {
+		return err
+	}
+
+	maxAllowedPacket := ds.Options.Get("maxAllowedPacket")
+	if maxAllowedPacket != "" {
+		ds.MaxAllowedPacket, err = strconv.Atoi(maxAllowedPacket)
+		if err != nil || ds.MaxAllowedPacket < 0 {
+			return fmt.Errorf("invalid value for maxAllowedPacket option (was %s)", maxAllowedPacket)
+		}
+	}
+
+	connAttrs := ds.Options.Get("connectionAttributes")
+	if connAttrs == "false" {
+		ds.DisableConnectionAttributes = true
+	} else if connAttrs != "" {
+		ds.ConnectionAttributes = map[string]string{}
+		for _, attr := range strings.Split(connAttrs, ",") {
+			k, v, ok := strings.Cut(attr, ":")
+			k = strings.TrimSpace(k)
+			if !ok || k == "" {
+				return fmt.Errorf("invalid value for connectionAttributes option (was %s)", connAttrs)
+			}
+			ds.ConnectionAttributes[k] = strings.TrimSpace(v)
+		}
+	}
+
+	compression := ds.Options.Get("compression")
+	if compression != "" {
+		ds.Compression = strings.ToLower(compression)
+		if !xstrings.SliceHas(compressionModes, ds.Compression) {
+			return fmt.Errorf("invalid value for compression option (was %s)", compression)
+		}
+	}
+
+	algorithms := ds.Options.Get("compressionAlgorithms")
+	if algorithms != "" {
+		for _, name := range strings.Split(algorithms, ",") {
+			a, err := xmysql.ParseCompressionAlgorithm(strings.TrimSpace(name))
+			if err != nil {
+				return fmt.Errorf("invalid value for compressionAlgorithms option (was %s)", algorithms)
+			}
+			ds.CompressionAlgorithms = append(ds.CompressionAlgorithms, a)
+		}
+	}
+
+	fallback := ds.Options.Get("fallbackAddresses")
+	if fallback != "" {
+		for _, addr := range strings.Split(fallback, ",") {
+			addr = strings.TrimSpace(addr)
+			if addr == "" {
+				return fmt.Errorf("invalid value for fallbackAddresses option (was %s)", fallback)
+			}
+			ds.FallbackAddresses = append(ds.FallbackAddresses, addr)
+		}
+	}
+
+	hosts := ds.Options.Get("hosts")
+	if hosts != "" {
+		if ds.Hosts, err = xmysql.ParseHosts(hosts); err != nil {
+			return fmt.Errorf("invalid value for hosts option (was %s)", hosts)
+		}
+	}
+
+	randomizeHosts := ds.Options.Get("randomizeHosts")
+	if randomizeHosts != "" {
+		ds.RandomizeHosts, err = xconv.ParseBool(randomizeHosts)
+		if err != nil {
+			return fmt.Errorf("invalid value for randomizeHosts option (was %s)", randomizeHosts)
+		}
+	}
+
+	ds.EnvPrefix = ds.Options.Get("envPrefix")
+
 	return nil
 }
+
+// parseTLSVersionOption parses value of the option name as TLS version, for
+// example, "TLSv1.3". An empty value results in zero.
+func parseTLSVersionOption(name, value string) (uint16, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	v, err := xmysql.ParseTLSVersion(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s option (was %s)", name, value)
+	}
+
+	return v, nil
+}
+
+// parseDurationOption parses value of the option name as time.Duration, for
+// example, "1s" or "500ms". An empty value results in zero.
+func parseDurationOption(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid value for %s option (was %s)", name, value)
+	}
+
+	return d, nil
+}
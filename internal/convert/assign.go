@@ -0,0 +1,300 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package convert
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golistic/pxmysql/decimal"
+)
+
+var errNilPtr = errors.New("destination pointer is nil")
+
+// Assign copies src into the value pointed to by dest, converting where
+// possible. It mimics what Go's database/sql does when scanning, but also
+// knows about the types pxmysql produces such as time.Duration, decimal.Decimal
+// and []string (MySQL SET).
+// When src implements driver.Valuer (for example the types of the null package),
+// its value is used. A nil src (SQL NULL) can only be stored in pointers,
+// interfaces, slices, maps, or destinations implementing sql.Scanner.
+func Assign(dest, src any) error {
+	if dest == nil {
+		return errNilPtr
+	}
+
+	if s, ok := dest.(sql.Scanner); ok {
+		return s.Scan(src)
+	}
+
+	if v, ok := src.(driver.Valuer); ok {
+		// destination could be of the same (nullable) type
+		if dv := reflect.ValueOf(dest); dv.Kind() == reflect.Pointer && !dv.IsNil() &&
+			reflect.TypeOf(src).AssignableTo(dv.Elem().Type()) {
+			dv.Elem().Set(reflect.ValueOf(src))
+			return nil
+		}
+
+		var err error
+		if src, err = v.Value(); err != nil {
+			return err
+		}
+	}
+
+	// fast paths for the common cases
+	switch d := dest.(type) {
+	case *any:
+		*d = src
+		return nil
+	case *string:
+		switch s := src.(type) {
+		case string:
+			*d = s
+			return nil
+		case []byte:
+			*d = string(s)
+			return nil
+		}
+	case *[]byte:
+		switch s := src.(type) {
+		case []byte:
+			*d = append([]byte(nil), s...)
+			return nil
+		case string:
+			*d = []byte(s)
+			return nil
+		case nil:
+			*d = nil
+			return nil
+		}
+	case *time.Time:
+		if s, ok := src.(time.Time); ok {
+			*d = s
+			return nil
+		}
+	case *time.Duration:
+		if s, ok := src.(time.Duration); ok {
+			*d = s
+			return nil
+		}
+	case *decimal.Decimal:
+		switch s := src.(type) {
+		case decimal.Decimal:
+			*d = s
+			return nil
+		case *decimal.Decimal:
+			*d = *s
+			return nil
+		case string:
+			v, err := decimal.New(s)
+			if err != nil {
+				return err
+			}
+			*d = *v
+			return nil
+		}
+	case *[]string:
+		switch s := src.(type) {
+		case []string:
+			*d = append([]string(nil), s...)
+			return nil
+		case nil:
+			*d = nil
+			return nil
+		}
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer {
+		return fmt.Errorf("destination not a pointer (was %T)", dest)
+	}
+	if dv.IsNil() {
+		return errNilPtr
+	}
+
+	return assignValue(dv.Elem(), src)
+}
+
+func assignValue(dv reflect.Value, src any) error {
+	if src == nil {
+		switch dv.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			dv.Set(reflect.Zero(dv.Type()))
+			return nil
+		default:
+			return fmt.Errorf("cannot store NULL into %s", dv.Type())
+		}
+	}
+
+	sv := reflect.ValueOf(src)
+
+	if sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+
+	if dv.Kind() == reflect.Pointer {
+		v := reflect.New(dv.Type().Elem())
+		if err := assignValue(v.Elem(), src); err != nil {
+			return err
+		}
+		dv.Set(v)
+		return nil
+	}
+
+	if dv.Kind() == sv.Kind() && sv.Type().ConvertibleTo(dv.Type()) {
+		dv.Set(sv.Convert(dv.Type()))
+		return nil
+	}
+
+	errConv := func(err error) error {
+		if err != nil {
+			return fmt.Errorf("converting %T to %s (%w)", src, dv.Type(), err)
+		}
+		return fmt.Errorf("converting %T to %s unsupported", src, dv.Type())
+	}
+
+	switch dv.Kind() {
+	case reflect.String:
+		s, ok := asString(src)
+		if !ok {
+			return errConv(nil)
+		}
+		dv.SetString(s)
+		return nil
+	case reflect.Bool:
+		s, ok := asString(src)
+		if !ok {
+			return errConv(nil)
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errConv(err)
+		}
+		dv.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch sv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i := sv.Int()
+			if dv.OverflowInt(i) {
+				return errConv(strconv.ErrRange)
+			}
+			dv.SetInt(i)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u := sv.Uint()
+			if int64(u) < 0 || dv.OverflowInt(int64(u)) {
+				return errConv(strconv.ErrRange)
+			}
+			dv.SetInt(int64(u))
+			return nil
+		}
+		s, ok := asString(src)
+		if !ok {
+			return errConv(nil)
+		}
+		i, err := strconv.ParseInt(s, 10, dv.Type().Bits())
+		if err != nil {
+			return errConv(err)
+		}
+		dv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch sv.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u := sv.Uint()
+			if dv.OverflowUint(u) {
+				return errConv(strconv.ErrRange)
+			}
+			dv.SetUint(u)
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i := sv.Int()
+			if i < 0 || dv.OverflowUint(uint64(i)) {
+				return errConv(strconv.ErrRange)
+			}
+			dv.SetUint(uint64(i))
+			return nil
+		}
+		s, ok := asString(src)
+		if !ok {
+			return errConv(nil)
+		}
+		u, err := strconv.ParseUint(s, 10, dv.Type().Bits())
+		if err != nil {
+			return errConv(err)
+		}
+		dv.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch sv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			dv.SetFloat(sv.Float())
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			dv.SetFloat(float64(sv.Int()))
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			dv.SetFloat(float64(sv.Uint()))
+			return nil
+		}
+		s, ok := asString(src)
+		if !ok {
+			return errConv(nil)
+		}
+		f, err := strconv.ParseFloat(s, dv.Type().Bits())
+		if err != nil {
+			return errConv(err)
+		}
+		dv.SetFloat(f)
+		return nil
+	}
+
+	return errConv(nil)
+}
+
+// asString returns the textual representation of src when src is of
+// a type which has one.
+func asString(src any) (string, bool) {
+	switch v := src.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case decimal.Decimal:
+		return v.String(), true
+	case *decimal.Decimal:
+		return v.String(), true
+	case []string:
+		return strings.Join(v, ","), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case time.Duration:
+		return v.String(), true
+	}
+
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 32), true
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), true
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true
+	case reflect.String:
+		return rv.String(), true
+	}
+
+	return "", false
+}
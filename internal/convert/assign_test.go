@@ -0,0 +1,97 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package convert_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/decimal"
+	"github.com/golistic/pxmysql/internal/convert"
+	"github.com/golistic/pxmysql/null"
+)
+
+func TestAssign(t *testing.T) {
+	t.Run("strings and bytes", func(t *testing.T) {
+		var s string
+		xt.OK(t, convert.Assign(&s, []byte("Sakila")))
+		xt.Eq(t, "Sakila", s)
+
+		var b []byte
+		xt.OK(t, convert.Assign(&b, "Sakila"))
+		xt.Eq(t, []byte("Sakila"), b)
+	})
+
+	t.Run("numeric", func(t *testing.T) {
+		var i int
+		xt.OK(t, convert.Assign(&i, uint64(42)))
+		xt.Eq(t, 42, i)
+
+		var u uint8
+		xt.OK(t, convert.Assign(&u, "200"))
+		xt.Eq(t, uint8(200), u)
+		xt.KO(t, convert.Assign(&u, int64(-1)))
+		xt.KO(t, convert.Assign(&u, int64(256)))
+
+		var f float64
+		xt.OK(t, convert.Assign(&f, float32(1.5)))
+		xt.Eq(t, 1.5, f)
+
+		var s string
+		xt.OK(t, convert.Assign(&s, int64(-7)))
+		xt.Eq(t, "-7", s)
+	})
+
+	t.Run("bool", func(t *testing.T) {
+		var b bool
+		xt.OK(t, convert.Assign(&b, int64(1)))
+		xt.Assert(t, b)
+	})
+
+	t.Run("named types", func(t *testing.T) {
+		type status string
+		var s status
+		xt.OK(t, convert.Assign(&s, "active"))
+		xt.Eq(t, status("active"), s)
+	})
+
+	t.Run("pointers and NULL", func(t *testing.T) {
+		var p *string
+		xt.OK(t, convert.Assign(&p, "Sakila"))
+		xt.Eq(t, "Sakila", *p)
+
+		xt.OK(t, convert.Assign(&p, nil))
+		xt.Eq(t, (*string)(nil), p)
+
+		var s string
+		xt.KO(t, convert.Assign(&s, nil))
+	})
+
+	t.Run("nullable source", func(t *testing.T) {
+		var ts time.Time
+		exp := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+		xt.OK(t, convert.Assign(&ts, null.Time{Time: exp, Valid: true}))
+		xt.Eq(t, exp, ts)
+
+		var ns null.String
+		xt.OK(t, convert.Assign(&ns, null.String{String: "Sakila", Valid: true}))
+		xt.Eq(t, "Sakila", ns.String)
+	})
+
+	t.Run("decimal", func(t *testing.T) {
+		var d decimal.Decimal
+		xt.OK(t, convert.Assign(&d, "3.14"))
+		xt.Eq(t, "3.14", d.String())
+
+		var s string
+		xt.OK(t, convert.Assign(&s, *decimal.MustNew("2.5")))
+		xt.Eq(t, "2.5", s)
+	})
+
+	t.Run("not a pointer", func(t *testing.T) {
+		var s string
+		xt.KO(t, convert.Assign(s, "Sakila"))
+	})
+}
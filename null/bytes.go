@@ -2,11 +2,7 @@
 
 package null
 
-import (
-	"bytes"
-	"database/sql/driver"
-	"fmt"
-)
+import "database/sql/driver"
 
 // Bytes represents a []byte (any MySQL BINARY types) that may be NULL.
 // This is not available in Go's sql package, and does not implement the Scanner interface.
@@ -18,35 +14,22 @@ type Bytes struct {
 var _ driver.Valuer = &Bytes{}
 var _ Nullable = &Bytes{}
 
+// Of returns n as the generic Of[[]byte].
+func (n Bytes) Of() Of[[]byte] {
+	return Of[[]byte]{V: n.Bytes, Valid: n.Valid}
+}
+
 // Compare returns whether value compares with the nullable Bytes.
 // It returns:
 // - true when Valid and stored Bytes is equal to value
 // - true when not Valid and value is nil
 // - false in any other case
 func (n Bytes) Compare(value any) bool {
-	if !n.Valid && value != nil {
-		return false
-	}
-
-	if value == nil {
-		return !n.Valid
-	}
-
-	switch v := value.(type) {
-	case []byte:
-		return bytes.Equal(n.Bytes, v)
-	case *[]byte:
-		return bytes.Equal(n.Bytes, *v)
-	default:
-		panic(fmt.Sprintf("value must be []byte or *[]byte; not %T", value))
-	}
+	return n.Of().Compare(value)
 }
 
 // Value returns the value of n and implements the driver.Valuer
 // as well as Nullable interface.
 func (n Bytes) Value() (driver.Value, error) {
-	if !n.Valid {
-		return nil, nil
-	}
-	return n.Bytes, nil
+	return n.Of().Value()
 }
@@ -4,7 +4,6 @@ package null
 
 import (
 	"database/sql/driver"
-	"fmt"
 
 	"github.com/golistic/pxmysql/decimal"
 )
@@ -19,28 +18,18 @@ type Decimal struct {
 var _ driver.Valuer = &Decimal{}
 var _ Nullable = &Decimal{}
 
+// Of returns nd as the generic Of[decimal.Decimal].
+func (nd Decimal) Of() Of[decimal.Decimal] {
+	return Of[decimal.Decimal]{V: nd.Decimal, Valid: nd.Valid}
+}
+
 // Compare returns whether value compares with the nullable Decimal.
 // It returns:
 // - true when Valid and stored Decimal is equal to value
 // - true when not Valid and value is nil
 // - false in any other case
 func (nd Decimal) Compare(value any) bool {
-	if !nd.Valid && value != nil {
-		return false
-	}
-
-	if value == nil {
-		return !nd.Valid
-	}
-
-	switch v := value.(type) {
-	case decimal.Decimal:
-		return nd.Decimal.Equal(v)
-	case *decimal.Decimal:
-		return nd.Decimal.Equal(*v)
-	default:
-		panic(fmt.Sprintf("value is of unsupported type %T", value))
-	}
+	return nd.Of().Compare(value)
 }
 
 // Value returns the value of n and implements the driver.Valuer
@@ -4,7 +4,6 @@ package null
 
 import (
 	"database/sql/driver"
-	"fmt"
 	"time"
 )
 
@@ -19,28 +18,18 @@ type Duration struct {
 var _ driver.Valuer = &Duration{}
 var _ Nullable = &Duration{}
 
+// Of returns nd as the generic Of[time.Duration].
+func (nd Duration) Of() Of[time.Duration] {
+	return Of[time.Duration]{V: nd.Duration, Valid: nd.Valid}
+}
+
 // Compare returns whether value compares with the nullable Duration.
 // It returns:
 // - true when Valid and stored Duration is equal to value
 // - true when not Valid and value is nil
 // - false in any other case
 func (nd Duration) Compare(value any) bool {
-	if !nd.Valid && value != nil {
-		return false
-	}
-
-	if value == nil {
-		return !nd.Valid
-	}
-
-	switch v := value.(type) {
-	case time.Duration:
-		return nd.Duration == v
-	case *time.Duration:
-		return nd.Duration == *v
-	default:
-		panic(fmt.Sprintf("value must be time.Duration or *time.Duration; not %T", value))
-	}
+	return nd.Of().Compare(value)
 }
 
 // Value returns the value of n and implements the driver.Valuer
@@ -2,10 +2,7 @@
 
 package null
 
-import (
-	"database/sql/driver"
-	"fmt"
-)
+import "database/sql/driver"
 
 // Float32 represents a float32 (any MySQL FLOAT type) that may be NULL.
 // This is similar to sql.NullFloat64, and does not implement the Scanner interface.
@@ -17,28 +14,18 @@ type Float32 struct {
 var _ driver.Valuer = &Float32{}
 var _ Nullable = &Float32{}
 
+// Of returns nf as the generic Of[float32].
+func (nf Float32) Of() Of[float32] {
+	return Of[float32]{V: nf.Float32, Valid: nf.Valid}
+}
+
 // Compare returns whether value compares with the nullable Float32.
 // It returns:
 // - true when Valid and stored Float32 is equal to value
 // - true when not Valid and value is nil
 // - false in any other case
 func (nf Float32) Compare(value any) bool {
-	if !nf.Valid && value != nil {
-		return false
-	}
-
-	if value == nil {
-		return !nf.Valid
-	}
-
-	switch v := value.(type) {
-	case float32:
-		return v == nf.Float32
-	case *float32:
-		return *v == nf.Float32
-	default:
-		panic(fmt.Sprintf("value is of unsupported type %T", value))
-	}
+	return nf.Of().Compare(value)
 }
 
 // Value returns the value of n and implements the driver.Valuer
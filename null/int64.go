@@ -19,6 +19,11 @@ type Int64 struct {
 var _ driver.Valuer = &Int64{}
 var _ Nullable = &Int64{}
 
+// Of returns ni as the generic Of[int64].
+func (ni Int64) Of() Of[int64] {
+	return Of[int64]{V: ni.Int64, Valid: ni.Valid}
+}
+
 // Compare returns whether value compares with the nullable Duration.
 // It returns:
 // - true when Valid and stored Duration is equal to value
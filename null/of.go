@@ -0,0 +1,201 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package null
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/golistic/pxmysql/decimal"
+	"github.com/golistic/pxmysql/internal/convert"
+)
+
+// Of represents a value of type T that may be NULL. It is the generic
+// counterpart of the named types such as String, Int64, or Time, and
+// can be used instead of them so that all nullable values are handled
+// the same way.
+// Unlike the named types, Of implements the sql.Scanner, json.Marshaler and
+// json.Unmarshaler interfaces.
+type Of[T any] struct {
+	V     T
+	Valid bool
+}
+
+var (
+	_ driver.Valuer    = Of[string]{}
+	_ Nullable         = Of[string]{}
+	_ sql.Scanner      = &Of[string]{}
+	_ json.Marshaler   = Of[string]{}
+	_ json.Unmarshaler = &Of[string]{}
+)
+
+// New returns v as valid, not NULL, Of[T].
+func New[T any](v T) Of[T] {
+	return Of[T]{V: v, Valid: true}
+}
+
+// FromPtr returns Of[T] using the value p points to. When p is nil, the
+// returned value is not valid (it is NULL).
+func FromPtr[T any](p *T) Of[T] {
+	if p == nil {
+		return Of[T]{}
+	}
+	return New(*p)
+}
+
+// Ptr returns a pointer to a copy of the stored value, or nil when n is
+// not valid (it is NULL).
+func (n Of[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
+
+// Compare returns whether value compares with the nullable n.
+// It returns:
+// - true when Valid and stored value is equal to value (of type T or *T)
+// - true when not Valid and value is nil
+// - false in any other case
+//
+// When T has an Equal method (like time.Time), it is used.
+// Panics when value is not of type T or *T.
+func (n Of[T]) Compare(value any) bool {
+	if !n.Valid && value != nil {
+		return false
+	}
+
+	if value == nil {
+		return !n.Valid
+	}
+
+	var other T
+	switch v := value.(type) {
+	case T:
+		other = v
+	case *T:
+		other = *v
+	default:
+		panic(fmt.Sprintf("value must be %T or *%T; not %T", other, other, value))
+	}
+
+	switch e := any(&n.V).(type) {
+	case interface{ Equal(T) bool }:
+		return e.Equal(other)
+	case *[]byte:
+		return bytes.Equal(*e, any(other).([]byte))
+	default:
+		return reflect.DeepEqual(n.V, other)
+	}
+}
+
+// Value returns the value of n and implements the driver.Valuer
+// as well as Nullable interface.
+//
+// The value is converted to one of the types of driver.Value where
+// possible: signed integers and time.Duration (nanoseconds) to int64,
+// unsigned integers to int64 when they fit, float32 to float64 keeping
+// its shortest decimal representation, decimal.Decimal to string, and
+// types based on bool, string, or []byte to these types. When T implements
+// driver.Valuer, its value is returned. Other values, such as unsigned
+// integers larger than math.MaxInt64 or []string, are returned as is; this
+// package's driver accepts them, but others might not.
+func (n Of[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	switch v := any(n.V).(type) {
+	case nil, int64, float64, bool, []byte, string, time.Time:
+		return v, nil
+	case time.Duration:
+		return int64(v), nil
+	case decimal.Decimal:
+		return v.String(), nil
+	case driver.Valuer:
+		return v.Value()
+	}
+
+	rv := reflect.ValueOf(n.V)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+	case reflect.Float32:
+		return strconv.ParseFloat(strconv.FormatFloat(rv.Float(), 'g', -1, 32), 64)
+	case reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+	}
+
+	return n.V, nil
+}
+
+// Scan implements the sql.Scanner interface. A nil src makes n
+// not valid (NULL); otherwise src is converted to T.
+func (n *Of[T]) Scan(src any) error {
+	if v, ok := src.(driver.Valuer); ok {
+		var err error
+		if src, err = v.Value(); err != nil {
+			return err
+		}
+	}
+
+	if src == nil {
+		var zero T
+		n.V, n.Valid = zero, false
+		return nil
+	}
+
+	if err := convert.Assign(&n.V, src); err != nil {
+		n.Valid = false
+		return err
+	}
+	n.Valid = true
+
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. When n is not
+// valid, it is encoded as JSON null.
+func (n Of[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. JSON null
+// makes n not valid.
+func (n *Of[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		var zero T
+		n.V, n.Valid = zero, false
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package null
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/decimal"
+)
+
+func TestOf_Compare(t *testing.T) {
+	var cases = []struct {
+		n     Nullable
+		value any
+		exp   bool
+	}{
+		{
+			n:     New("Sakila"),
+			value: "Sakila",
+			exp:   true,
+		},
+		{
+			n:     New("Sakila"),
+			value: stringPtr("Go gopher"),
+			exp:   false,
+		},
+		{
+			n:     New([]byte("Sakila")),
+			value: []byte("Sakila"),
+			exp:   true,
+		},
+		{
+			n:     New(time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)),
+			value: time.Date(2023, 9, 1, 12, 0, 0, 0, time.FixedZone("", 7200)),
+			exp:   true,
+		},
+		{
+			n:     New(*decimal.MustNew("3.14")),
+			value: decimal.MustNew("3.14"),
+			exp:   true,
+		},
+		{
+			n:     Of[int64]{},
+			value: nil,
+			exp:   true,
+		},
+		{
+			n:     Of[int64]{},
+			value: int64(0),
+			exp:   false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			xt.Eq(t, c.exp, c.n.Compare(c.value))
+		})
+	}
+
+	t.Run("panics if value type is not supported", func(t *testing.T) {
+		xt.Panics(t, func() {
+			_ = New("Sakila").Compare(123)
+		})
+	})
+}
+
+func TestOf_Value(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		v, err := New(int64(42)).Value()
+		xt.OK(t, err)
+		xt.Eq(t, int64(42), v.(int64))
+	})
+
+	t.Run("not valid", func(t *testing.T) {
+		v, err := Of[int64]{V: 42}.Value()
+		xt.OK(t, err)
+		xt.Eq(t, nil, v)
+	})
+
+	t.Run("converted to driver values", func(t *testing.T) {
+		type label string
+		now := time.Now()
+
+		var cases = []struct {
+			n   Nullable
+			exp any
+		}{
+			{n: New(int32(-7)), exp: int64(-7)},
+			{n: New(uint16(7)), exp: int64(7)},
+			{n: New(uint64(math.MaxUint64)), exp: uint64(math.MaxUint64)},
+			{n: New(float32(1.1)), exp: 1.1},
+			{n: New(3 * time.Second), exp: int64(3 * time.Second)},
+			{n: New(*decimal.MustNew("3.14")), exp: "3.14"},
+			{n: New(label("shop")), exp: "shop"},
+			{n: New(Time{Time: now, Valid: true}), exp: now},
+			{n: New(now), exp: now},
+			{n: New([]byte("abc")), exp: []byte("abc")},
+		}
+
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%T", c.n), func(t *testing.T) {
+				v, err := c.n.Value()
+				xt.OK(t, err)
+				xt.Eq(t, c.exp, v)
+			})
+		}
+	})
+}
+
+func TestOf_Scan(t *testing.T) {
+	t.Run("nil makes not valid", func(t *testing.T) {
+		n := New("Sakila")
+		xt.OK(t, n.Scan(nil))
+		xt.Assert(t, !n.Valid)
+		xt.Eq(t, "", n.V)
+	})
+
+	t.Run("converts", func(t *testing.T) {
+		var n Of[int]
+		xt.OK(t, n.Scan(int64(42)))
+		xt.Assert(t, n.Valid)
+		xt.Eq(t, 42, n.V)
+
+		var s Of[string]
+		xt.OK(t, s.Scan([]byte("Sakila")))
+		xt.Eq(t, "Sakila", s.V)
+	})
+
+	t.Run("named nullable", func(t *testing.T) {
+		var n Of[string]
+		xt.OK(t, n.Scan(String{String: "Sakila", Valid: true}))
+		xt.Assert(t, n.Valid)
+		xt.Eq(t, "Sakila", n.V)
+
+		xt.OK(t, n.Scan(String{}))
+		xt.Assert(t, !n.Valid)
+	})
+
+	t.Run("conversion error", func(t *testing.T) {
+		var n Of[int8]
+		xt.KO(t, n.Scan(int64(1024)))
+		xt.Assert(t, !n.Valid)
+	})
+}
+
+func TestOf_JSON(t *testing.T) {
+	type doc struct {
+		Name Of[string] `json:"name"`
+		Age  Of[int]    `json:"age"`
+	}
+
+	t.Run("marshal", func(t *testing.T) {
+		b, err := json.Marshal(doc{Name: New("Sakila")})
+		xt.OK(t, err)
+		xt.Eq(t, `{"name":"Sakila","age":null}`, string(b))
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		var d doc
+		xt.OK(t, json.Unmarshal([]byte(`{"name":null,"age":7}`), &d))
+		xt.Assert(t, !d.Name.Valid)
+		xt.Assert(t, d.Age.Valid)
+		xt.Eq(t, 7, d.Age.V)
+	})
+}
+
+func TestOf_Ptr(t *testing.T) {
+	xt.Eq(t, (*string)(nil), Of[string]{}.Ptr())
+	xt.Eq(t, "Sakila", *New("Sakila").Ptr())
+
+	xt.Assert(t, !FromPtr[string](nil).Valid)
+	xt.Eq(t, "Sakila", FromPtr(stringPtr("Sakila")).V)
+}
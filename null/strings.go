@@ -19,6 +19,11 @@ type Strings struct {
 var _ driver.Valuer = &Strings{}
 var _ Nullable = &Strings{}
 
+// Of returns ns as the generic Of[[]string].
+func (ns Strings) Of() Of[[]string] {
+	return Of[[]string]{V: ns.Strings, Valid: ns.Valid}
+}
+
 // Compare returns whether value compares with the nullable Strings.
 // It returns:
 // - true when Valid and stored Strings is equal to value
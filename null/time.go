@@ -4,7 +4,6 @@ package null
 
 import (
 	"database/sql/driver"
-	"fmt"
 	"time"
 )
 
@@ -18,35 +17,22 @@ type Time struct {
 var _ driver.Valuer = &Time{}
 var _ Nullable = &Time{}
 
+// Of returns nd as the generic Of[time.Time].
+func (nd Time) Of() Of[time.Time] {
+	return Of[time.Time]{V: nd.Time, Valid: nd.Valid}
+}
+
 // Compare returns whether value compares with the nullable Time.
 // It returns:
 // - true when Valid and stored Time is equal to value
 // - true when not Valid and value is nil
 // - false in any other case
 func (nd Time) Compare(value any) bool {
-	if !nd.Valid && value != nil {
-		return false
-	}
-
-	if value == nil {
-		return !nd.Valid
-	}
-
-	switch v := value.(type) {
-	case time.Time:
-		return nd.Time.Equal(v)
-	case *time.Time:
-		return nd.Time.Equal(*v)
-	default:
-		panic(fmt.Sprintf("value must be time.Time or *time.Time; not %T", value))
-	}
+	return nd.Of().Compare(value)
 }
 
 // Value returns the value of n and implements the driver.Valuer
 // as well as Nullable interface.
 func (nd Time) Value() (driver.Value, error) {
-	if !nd.Valid {
-		return nil, nil
-	}
-	return nd.Time, nil
+	return nd.Of().Value()
 }
@@ -19,6 +19,11 @@ type Uint64 struct {
 var _ driver.Valuer = &Uint64{}
 var _ Nullable = &Uint64{}
 
+// Of returns ni as the generic Of[uint64].
+func (ni Uint64) Of() Of[uint64] {
+	return Of[uint64]{V: ni.Uint64, Valid: ni.Valid}
+}
+
 // Compare returns whether value compares with the nullable Uint64.
 // It returns:
 // - true when Valid and stored Uint64 is equal to value
@@ -0,0 +1,512 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package pxmysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golistic/pxmysql/xmysql"
+)
+
+// DefaultReplicaCheckInterval is the default interval between health checks
+// of a replica.
+const DefaultReplicaCheckInterval = 5 * time.Second
+
+type ctxKey struct{}
+
+var ctxReadOnly = &ctxKey{}
+
+// SetContextReadOnly marks ctx so that queries executed with it by connections
+// of a connector returned by NewReadWriteConnector are sent to a replica.
+func SetContextReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxReadOnly, true)
+}
+
+// ContextReadOnly returns whether ctx was marked using SetContextReadOnly.
+func ContextReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(ctxReadOnly).(bool)
+	return readOnly
+}
+
+// ReadWriteConfig is the configuration of a connector splitting reads and
+// writes between a primary and its replicas.
+type ReadWriteConfig struct {
+	// Primary is the configuration of the primary, which gets everything
+	// not sent to a replica.
+	Primary *xmysql.ConnectConfig
+	// Replicas are the configurations of the replicas, which get read-only
+	// transactions and queries executed with a context marked using
+	// SetContextReadOnly.
+	Replicas []*xmysql.ConnectConfig
+
+	// LagQuery is executed on a replica to check its health, and returns the
+	// replication lag in seconds as single value. NULL means the replica is not
+	// replicating. When empty, replicas are only checked for being reachable.
+	LagQuery string
+	// MaxLag is the maximum replication lag; replicas lagging more are
+	// excluded until checked again. It is required with LagQuery.
+	MaxLag time.Duration
+	// CheckInterval is the interval between health checks of a replica
+	// (default DefaultReplicaCheckInterval). Checks are done in the background
+	// using a separate session with each replica.
+	CheckInterval time.Duration
+
+	// InterpolateParams, StmtCacheSize, and ResetSession are used for the
+	// sessions with the primary and the replicas, like the DSN options
+	// interpolateParams, stmtCacheSize, and resetSession (see DataSource).
+	InterpolateParams bool
+	StmtCacheSize     int
+	ResetSession      bool
+}
+
+type readWriteConnector struct {
+	config ReadWriteConfig
+
+	mu sync.Mutex
+	// healthy holds, by index of the replica, the result of its last health
+	// check; replicas not checked yet are missing.
+	healthy map[int]bool
+
+	monitorOnce sync.Once
+	closeOnce   sync.Once
+	// stop is closed to stop the monitor, which closes done when it returns.
+	stop chan struct{}
+	done chan struct{}
+}
+
+var (
+	_ driver.Connector = (*readWriteConnector)(nil)
+	_ io.Closer        = (*readWriteConnector)(nil)
+)
+
+// NewReadWriteConnector returns a connector, which is used with sql.OpenDB,
+// splitting reads and writes. Each connection opens a session with the
+// primary, and when needed, a session with a healthy replica. Read-only
+// transactions, and queries executed with a context marked using
+// SetContextReadOnly, are sent to the replica. Everything else, including
+// all statements of other transactions, is sent to the primary. When no
+// replica is healthy, the primary is used.
+// Replicas are checked in the background; the checks stop when the sql.DB
+// using the connector is closed.
+func NewReadWriteConnector(config ReadWriteConfig) (driver.Connector, error) {
+	if config.Primary == nil {
+		return nil, fmt.Errorf("primary configuration missing")
+	}
+
+	if config.LagQuery != "" && config.MaxLag <= 0 {
+		return nil, fmt.Errorf("maximum lag required with lag query")
+	}
+
+	if config.StmtCacheSize < 0 {
+		return nil, fmt.Errorf("statement cache size must not be negative")
+	}
+
+	if config.CheckInterval == 0 {
+		config.CheckInterval = DefaultReplicaCheckInterval
+	}
+
+	config.Primary = cloneConnectConfig(config.Primary)
+
+	replicas := make([]*xmysql.ConnectConfig, len(config.Replicas))
+	for i, r := range config.Replicas {
+		if r == nil {
+			return nil, fmt.Errorf("replica %d configuration missing", i)
+		}
+		replicas[i] = cloneConnectConfig(r)
+	}
+	config.Replicas = replicas
+
+	return &readWriteConnector{
+		config:  config,
+		healthy: map[int]bool{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+func (c *readWriteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if len(c.config.Replicas) > 0 {
+		c.monitorOnce.Do(func() {
+			go c.monitor()
+		})
+	}
+
+	primary, err := c.openConnection(ctx, c.config.Primary)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readWriteConnection{
+		connector: c,
+		primary:   primary,
+	}, nil
+}
+
+func (c *readWriteConnector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+// Close stops the health checks of the replicas. It is called by sql.DB.Close.
+func (c *readWriteConnector) Close() error {
+	c.closeOnce.Do(func() {
+		c.monitorOnce.Do(func() {
+			close(c.done) // monitor never started
+		})
+		close(c.stop)
+	})
+	<-c.done
+
+	return nil
+}
+
+// monitor checks the health of each replica every CheckInterval until the
+// connector is closed. Each replica is checked using its own connection,
+// so that checks are not done while executing queries.
+func (c *readWriteConnector) monitor() {
+	defer close(c.done)
+
+	conns := make([]*connection, len(c.config.Replicas))
+	defer func() {
+		for _, cnx := range conns {
+			if cnx != nil {
+				_ = cnx.Close()
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(c.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for i := range conns {
+			conns[i] = c.monitorReplica(i, conns[i])
+		}
+	}
+}
+
+// monitorReplica checks the health of replica i using cnx, opening it when
+// nil, and returns the connection to use for the next check.
+func (c *readWriteConnector) monitorReplica(i int, cnx *connection) *connection {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.CheckInterval)
+	defer cancel()
+
+	var err error
+	if cnx == nil {
+		if cnx, err = c.openConnection(ctx, c.config.Replicas[i]); err != nil {
+			c.setHealthy(i, false)
+			return nil
+		}
+	}
+
+	if err := c.checkReplica(ctx, cnx); err != nil {
+		c.setHealthy(i, false)
+		_ = cnx.Close()
+		return nil
+	}
+
+	c.setHealthy(i, true)
+	return cnx
+}
+
+// openReplica opens a connection to a healthy replica. Replicas are tried in
+// random order, skipping those which failed their last health check. A
+// replica not checked yet is checked using the opened connection. It returns
+// the connection and the index of the replica, or nil when no replica is
+// healthy.
+func (c *readWriteConnector) openReplica(ctx context.Context) (*connection, int) {
+	for _, i := range rand.Perm(len(c.config.Replicas)) {
+		healthy, checked := c.health(i)
+		if checked && !healthy {
+			continue
+		}
+
+		cnx, err := c.openConnection(ctx, c.config.Replicas[i])
+		if err == nil && !checked {
+			if err = c.checkReplica(ctx, cnx); err != nil {
+				_ = cnx.Close()
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break // not the fault of the replica
+			}
+			c.setHealthy(i, false)
+			continue
+		}
+
+		if !checked {
+			c.setHealthy(i, true)
+		}
+		return cnx, i
+	}
+
+	return nil, -1
+}
+
+// openConnection opens a connection using config.
+func (c *readWriteConnector) openConnection(ctx context.Context, config *xmysql.ConnectConfig) (*connection, error) {
+	ses, err := xmysql.GetSession(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	cnx := &connection{
+		cfg:               config,
+		session:           ses,
+		interpolateParams: c.config.InterpolateParams,
+		resetSession:      c.config.ResetSession,
+	}
+
+	if c.config.StmtCacheSize > 0 {
+		cnx.stmtCache = newStmtCache(c.config.StmtCacheSize)
+	}
+
+	return cnx, nil
+}
+
+// checkReplica returns an error when the replica using cnx is not healthy.
+func (c *readWriteConnector) checkReplica(ctx context.Context, cnx *connection) error {
+	if c.config.LagQuery == "" {
+		return cnx.Ping(ctx)
+	}
+
+	res, err := cnx.session.ExecuteStatement(ctx, c.config.LagQuery)
+	if err != nil {
+		return fmt.Errorf("failed getting replication lag (%w)", err)
+	}
+
+	if len(res.Rows) == 0 || len(res.Rows[0].Values) == 0 {
+		return fmt.Errorf("lag query returned no value")
+	}
+
+	var seconds float64
+	switch v := res.Rows[0].Values[0].(type) {
+	case nil:
+		return fmt.Errorf("replica is not replicating")
+	case int64:
+		seconds = float64(v)
+	case uint64:
+		seconds = float64(v)
+	case float64:
+		seconds = v
+	case float32:
+		seconds = float64(v)
+	default:
+		if seconds, err = strconv.ParseFloat(fmt.Sprint(v), 64); err != nil {
+			return fmt.Errorf("lag query returned invalid value (was %v)", v)
+		}
+	}
+
+	if lag := time.Duration(seconds * float64(time.Second)); lag > c.config.MaxLag {
+		return fmt.Errorf("replication lag %s exceeds maximum %s", lag, c.config.MaxLag)
+	}
+
+	return nil
+}
+
+// health returns whether replica i passed its last health check, and whether
+// it was checked at all.
+func (c *readWriteConnector) health(i int) (healthy, checked bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	healthy, checked = c.healthy[i]
+	return healthy, checked
+}
+
+func (c *readWriteConnector) setHealthy(i int, healthy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.healthy[i] = healthy
+}
+
+type readWriteConnection struct {
+	connector *readWriteConnector
+	primary   *connection
+	// replica is nil until a query is sent to a replica.
+	replica *connection
+	// replicaIndex is the index of the replica within ReadWriteConfig.Replicas.
+	replicaIndex int
+	// tx is the connection of the active transaction, if any.
+	tx *connection
+}
+
+var (
+	_ driver.Conn               = (*readWriteConnection)(nil)
+	_ driver.ConnBeginTx        = (*readWriteConnection)(nil)
+	_ driver.ConnPrepareContext = (*readWriteConnection)(nil)
+	_ driver.Pinger             = (*readWriteConnection)(nil)
+	_ driver.ExecerContext      = (*readWriteConnection)(nil)
+	_ driver.QueryerContext     = (*readWriteConnection)(nil)
+	_ driver.NamedValueChecker  = (*readWriteConnection)(nil)
+	_ driver.SessionResetter    = (*readWriteConnection)(nil)
+)
+
+func (c *readWriteConnection) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *readWriteConnection) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.route(ctx).PrepareContext(ctx, query)
+}
+
+func (c *readWriteConnection) Close() error {
+	var errs []error
+
+	if err := c.primary.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.replica != nil {
+		if err := c.replica.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *readWriteConnection) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx starts a transaction on a replica when opts.ReadOnly is set, and on
+// the primary otherwise. All statements executed until the transaction ends
+// are sent to the same server.
+func (c *readWriteConnection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	cnx := c.primary
+	if opts.ReadOnly {
+		if r := c.replicaConnection(ctx); r != nil {
+			cnx = r
+		}
+	}
+
+	tx, err := cnx.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.tx = cnx
+
+	return &readWriteTx{Tx: tx, cnx: c}, nil
+}
+
+func (c *readWriteConnection) Ping(ctx context.Context) error {
+	return c.primary.Ping(ctx)
+}
+
+func (c *readWriteConnection) ExecContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Result, error) {
+
+	return c.route(ctx).ExecContext(ctx, query, args)
+}
+
+func (c *readWriteConnection) QueryContext(ctx context.Context, query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+
+	return c.route(ctx).QueryContext(ctx, query, args)
+}
+
+// ResetSession resets the sessions with the primary and the replica when
+// ReadWriteConfig.ResetSession is set.
+func (c *readWriteConnection) ResetSession(ctx context.Context) error {
+	if err := c.primary.ResetSession(ctx); err != nil {
+		return err
+	}
+
+	if c.replica != nil {
+		if err := c.replica.ResetSession(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *readWriteConnection) CheckNamedValue(nv *driver.NamedValue) error {
+	return c.primary.CheckNamedValue(nv)
+}
+
+// Replica returns the index within ReadWriteConfig.Replicas of the replica
+// the connection uses, or -1 when it does not use any. The connection can
+// be retrieved using sql.Conn.Raw and asserting the interface
+// `interface{ Replica() int }`.
+func (c *readWriteConnection) Replica() int {
+	if c.replica == nil {
+		return -1
+	}
+	return c.replicaIndex
+}
+
+// route returns the connection ctx is sent to.
+func (c *readWriteConnection) route(ctx context.Context) *connection {
+	if c.tx != nil {
+		return c.tx
+	}
+
+	if ContextReadOnly(ctx) {
+		if r := c.replicaConnection(ctx); r != nil {
+			return r
+		}
+	}
+
+	return c.primary
+}
+
+// replicaConnection returns the connection to a healthy replica, opening
+// one when needed. When the replica failed its last health check, done in
+// the background, another replica is used. It returns nil when no replica
+// is healthy.
+func (c *readWriteConnection) replicaConnection(ctx context.Context) *connection {
+	if c.replica != nil {
+		if healthy, _ := c.connector.health(c.replicaIndex); !healthy {
+			_ = c.replica.Close()
+			c.replica = nil
+		}
+	}
+
+	if c.replica == nil {
+		c.replica, c.replicaIndex = c.connector.openReplica(ctx)
+	}
+
+	return c.replica
+}
+
+// readWriteTx ends the routing of statements to the connection of the
+// transaction when it is committed or rolled back.
+type readWriteTx struct {
+	driver.Tx
+	cnx *readWriteConnection
+}
+
+func (tx *readWriteTx) Commit() error {
+	tx.cnx.tx = nil
+	return tx.Tx.Commit()
+}
+
+func (tx *readWriteTx) Rollback() error {
+	tx.cnx.tx = nil
+	return tx.Tx.Rollback()
+}
+
+// cloneConnectConfig returns a copy of config including the password.
+func cloneConnectConfig(config *xmysql.ConnectConfig) *xmysql.ConnectConfig {
+	c := config.Clone()
+	c.Password = config.Password
+	return c
+}
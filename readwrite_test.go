@@ -0,0 +1,260 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package pxmysql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql"
+	"github.com/golistic/pxmysql/xmysql"
+)
+
+func TestNewReadWriteConnector(t *testing.T) {
+	t.Run("primary configuration missing", func(t *testing.T) {
+		_, err := pxmysql.NewReadWriteConnector(pxmysql.ReadWriteConfig{})
+		xt.KO(t, err)
+		xt.Eq(t, "primary configuration missing", err.Error())
+	})
+
+	t.Run("replica configuration missing", func(t *testing.T) {
+		_, err := pxmysql.NewReadWriteConnector(pxmysql.ReadWriteConfig{
+			Primary:  &xmysql.ConnectConfig{},
+			Replicas: []*xmysql.ConnectConfig{{}, nil},
+		})
+		xt.KO(t, err)
+		xt.Eq(t, "replica 1 configuration missing", err.Error())
+	})
+
+	t.Run("statement cache size must not be negative", func(t *testing.T) {
+		_, err := pxmysql.NewReadWriteConnector(pxmysql.ReadWriteConfig{
+			Primary:       &xmysql.ConnectConfig{},
+			StmtCacheSize: -1,
+		})
+		xt.KO(t, err)
+		xt.Eq(t, "statement cache size must not be negative", err.Error())
+	})
+
+	t.Run("maximum lag required with lag query", func(t *testing.T) {
+		_, err := pxmysql.NewReadWriteConnector(pxmysql.ReadWriteConfig{
+			Primary:  &xmysql.ConnectConfig{},
+			LagQuery: "SELECT 0",
+		})
+		xt.KO(t, err)
+		xt.Eq(t, "maximum lag required with lag query", err.Error())
+	})
+}
+
+func TestReadWriteConnector(t *testing.T) {
+	username, password := getCredentials()
+
+	config := func(address string) *xmysql.ConnectConfig {
+		cfg := &xmysql.ConnectConfig{
+			Address:  address,
+			Username: username,
+			Schema:   testSchema,
+			UseTLS:   true,
+		}
+		cfg.SetPassword(password)
+		return cfg
+	}
+
+	// openDB returns a database with a single connection, so that the
+	// primary and replica sessions of the connection can be compared.
+	openDB := func(t *testing.T, rwConfig pxmysql.ReadWriteConfig) *sql.DB {
+		t.Helper()
+
+		connector, err := pxmysql.NewReadWriteConnector(rwConfig)
+		xt.OK(t, err)
+
+		db := sql.OpenDB(connector)
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { _ = db.Close() })
+
+		return db
+	}
+
+	connectionID := func(t *testing.T, ctx context.Context, db *sql.DB) int {
+		t.Helper()
+
+		var id int
+		xt.OK(t, db.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id))
+		return id
+	}
+
+	readOnly := pxmysql.SetContextReadOnly(context.Background())
+
+	t.Run("marked queries are sent to replica", func(t *testing.T) {
+		db := openDB(t, pxmysql.ReadWriteConfig{
+			Primary:  config(testContext.XPluginAddr),
+			Replicas: []*xmysql.ConnectConfig{config(testContext.XPluginAddr)},
+		})
+
+		primaryID := connectionID(t, context.Background(), db)
+		replicaID := connectionID(t, readOnly, db)
+		xt.Assert(t, primaryID != replicaID)
+		xt.Eq(t, replicaID, connectionID(t, readOnly, db))
+		xt.Eq(t, primaryID, connectionID(t, context.Background(), db))
+	})
+
+	t.Run("read-only transactions are sent to replica", func(t *testing.T) {
+		db := openDB(t, pxmysql.ReadWriteConfig{
+			Primary:  config(testContext.XPluginAddr),
+			Replicas: []*xmysql.ConnectConfig{config(testContext.XPluginAddr)},
+		})
+
+		tbl := "t_rw_read_only_tx"
+		_, err := db.Exec("DROP TABLE IF EXISTS " + tbl)
+		xt.OK(t, err)
+		_, err = db.Exec("CREATE TABLE " + tbl + " (id INT)")
+		xt.OK(t, err)
+
+		primaryID := connectionID(t, context.Background(), db)
+
+		tx, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+		xt.OK(t, err)
+
+		var txID int
+		xt.OK(t, tx.QueryRow("SELECT CONNECTION_ID()").Scan(&txID))
+		xt.Assert(t, primaryID != txID)
+
+		_, err = tx.Exec("INSERT INTO " + tbl + " VALUES (1)")
+		xt.KO(t, err) // cannot execute statement in a READ ONLY transaction
+
+		xt.OK(t, tx.Rollback())
+		xt.Eq(t, primaryID, connectionID(t, context.Background(), db))
+	})
+
+	t.Run("other transactions are sent to primary", func(t *testing.T) {
+		db := openDB(t, pxmysql.ReadWriteConfig{
+			Primary:  config(testContext.XPluginAddr),
+			Replicas: []*xmysql.ConnectConfig{config(testContext.XPluginAddr)},
+		})
+
+		primaryID := connectionID(t, context.Background(), db)
+
+		tx, err := db.BeginTx(readOnly, nil)
+		xt.OK(t, err)
+
+		var txID int
+		xt.OK(t, tx.QueryRowContext(readOnly, "SELECT CONNECTION_ID()").Scan(&txID))
+		xt.Eq(t, primaryID, txID)
+		xt.OK(t, tx.Commit())
+	})
+
+	t.Run("lagging replica is excluded", func(t *testing.T) {
+		db := openDB(t, pxmysql.ReadWriteConfig{
+			Primary:  config(testContext.XPluginAddr),
+			Replicas: []*xmysql.ConnectConfig{config(testContext.XPluginAddr)},
+			LagQuery: "SELECT 10",
+			MaxLag:   time.Second,
+		})
+
+		primaryID := connectionID(t, context.Background(), db)
+		xt.Eq(t, primaryID, connectionID(t, readOnly, db))
+	})
+
+	t.Run("replica not replicating is excluded", func(t *testing.T) {
+		db := openDB(t, pxmysql.ReadWriteConfig{
+			Primary:  config(testContext.XPluginAddr),
+			Replicas: []*xmysql.ConnectConfig{config(testContext.XPluginAddr)},
+			LagQuery: "SELECT NULL",
+			MaxLag:   time.Second,
+		})
+
+		primaryID := connectionID(t, context.Background(), db)
+		xt.Eq(t, primaryID, connectionID(t, readOnly, db))
+	})
+
+	t.Run("sessions are reset when reused", func(t *testing.T) {
+		db := openDB(t, pxmysql.ReadWriteConfig{
+			Primary:      config(testContext.XPluginAddr),
+			Replicas:     []*xmysql.ConnectConfig{config(testContext.XPluginAddr)},
+			ResetSession: true,
+		})
+
+		_, err := db.Exec("SET @rw_reset = 1")
+		xt.OK(t, err)
+		_, err = db.ExecContext(readOnly, "SET @rw_reset = 1")
+		xt.OK(t, err)
+
+		for _, ctx := range []context.Context{context.Background(), readOnly} {
+			var v sql.NullInt64
+			xt.OK(t, db.QueryRowContext(ctx, "SELECT @rw_reset").Scan(&v))
+			xt.Assert(t, !v.Valid)
+		}
+	})
+
+	t.Run("unreachable replica is skipped", func(t *testing.T) {
+		db := openDB(t, pxmysql.ReadWriteConfig{
+			Primary: config(testContext.XPluginAddr),
+			Replicas: []*xmysql.ConnectConfig{
+				config("127.0.0.1:1"),
+			},
+			LagQuery: "SELECT 0",
+			MaxLag:   time.Second,
+		})
+
+		primaryID := connectionID(t, context.Background(), db)
+		xt.Eq(t, primaryID, connectionID(t, readOnly, db))
+
+		conn, err := db.Conn(context.Background())
+		xt.OK(t, err)
+		defer func() { _ = conn.Close() }()
+
+		xt.OK(t, conn.Raw(func(driverConn any) error {
+			xt.Eq(t, -1, driverConn.(interface{ Replica() int }).Replica())
+			return nil
+		}))
+	})
+}
+
+func TestReadWriteConnector_Close(t *testing.T) {
+	// neither the primary nor the replica is reachable
+	newConnector := func(t *testing.T) driver.Connector {
+		t.Helper()
+
+		c, err := pxmysql.NewReadWriteConnector(pxmysql.ReadWriteConfig{
+			Primary: &xmysql.ConnectConfig{Address: "127.0.0.1:1", ConnectTimeout: time.Second},
+			Replicas: []*xmysql.ConnectConfig{
+				{Address: "127.0.0.1:1", ConnectTimeout: time.Second},
+			},
+			CheckInterval: 10 * time.Millisecond,
+		})
+		xt.OK(t, err)
+
+		return c
+	}
+
+	t.Run("stops checking replicas", func(t *testing.T) {
+		c := newConnector(t)
+
+		// connecting starts checking the replicas in the background
+		_, err := c.Connect(context.Background())
+		xt.KO(t, err)
+		time.Sleep(50 * time.Millisecond)
+
+		closer, ok := c.(io.Closer)
+		xt.Assert(t, ok)
+		xt.OK(t, closer.Close())
+		xt.OK(t, closer.Close())
+	})
+
+	t.Run("without checking replicas", func(t *testing.T) {
+		closer := newConnector(t).(io.Closer)
+		xt.OK(t, closer.Close())
+		xt.OK(t, closer.Close())
+	})
+
+	t.Run("closed by sql.DB", func(t *testing.T) {
+		db := sql.OpenDB(newConnector(t))
+		xt.KO(t, db.Ping())
+		xt.OK(t, db.Close())
+	})
+}
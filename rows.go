@@ -5,7 +5,10 @@ package pxmysql
 import (
 	"database/sql/driver"
 	"io"
+	"reflect"
+	"time"
 
+	"github.com/golistic/pxmysql/decimal"
 	"github.com/golistic/pxmysql/null"
 	"github.com/golistic/pxmysql/xmysql"
 )
@@ -16,7 +19,14 @@ type rows struct {
 	currRowIndex int
 }
 
-var _ driver.Rows = &rows{}
+var (
+	_ driver.Rows                           = &rows{}
+	_ driver.RowsColumnTypeScanType         = &rows{}
+	_ driver.RowsColumnTypeDatabaseTypeName = &rows{}
+	_ driver.RowsColumnTypeNullable         = &rows{}
+	_ driver.RowsColumnTypeLength           = &rows{}
+	_ driver.RowsColumnTypePrecisionScale   = &rows{}
+)
 
 // Columns returns the names of the columns.
 func (r *rows) Columns() []string {
@@ -27,7 +37,7 @@ func (r *rows) Columns() []string {
 	cols := make([]string, len(r.xpresult.Columns))
 
 	for i, c := range r.xpresult.Columns {
-		cols[i] = string(c.Name)
+		cols[i] = c.Name
 	}
 
 	return cols
@@ -57,3 +67,97 @@ func (r *rows) Next(dest []driver.Value) error {
 	r.currRowIndex++
 	return nil
 }
+
+// ColumnTypeScanType returns the Go type of the values of the column
+// with given index as produced by Next. For columns which can be NULL,
+// the generic null.Of is used.
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	c := r.column(index)
+	if c == nil {
+		return reflect.TypeOf(new(any)).Elem()
+	}
+
+	var notNull, nullable any
+	switch c.Type {
+	case xmysql.ColumnTypeSInt:
+		notNull, nullable = int64(0), null.Of[int64]{}
+	case xmysql.ColumnTypeUInt, xmysql.ColumnTypeBit:
+		notNull, nullable = uint64(0), null.Of[uint64]{}
+	case xmysql.ColumnTypeDouble:
+		notNull, nullable = float64(0), null.Of[float64]{}
+	case xmysql.ColumnTypeFloat:
+		notNull, nullable = float32(0), null.Of[float32]{}
+	case xmysql.ColumnTypeBytes, xmysql.ColumnTypeEnum:
+		if xmysql.IsSupportedCollation(c.Collation) {
+			notNull, nullable = "", null.Of[string]{}
+		} else {
+			notNull, nullable = []byte{}, null.Of[[]byte]{}
+		}
+	case xmysql.ColumnTypeTime:
+		notNull, nullable = time.Duration(0), null.Of[time.Duration]{}
+	case xmysql.ColumnTypeDatetime:
+		notNull, nullable = time.Time{}, null.Of[time.Time]{}
+	case xmysql.ColumnTypeSet:
+		notNull, nullable = []string{}, null.Of[[]string]{}
+	case xmysql.ColumnTypeDecimal:
+		notNull, nullable = decimal.Decimal{}, null.Of[decimal.Decimal]{}
+	default:
+		return reflect.TypeOf(new(any)).Elem()
+	}
+
+	if c.NotNull() {
+		return reflect.TypeOf(notNull)
+	}
+	return reflect.TypeOf(nullable)
+}
+
+// ColumnTypeDatabaseTypeName returns the MySQL data type name of the column
+// with given index, for example "VARCHAR" or "BIGINT".
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	c := r.column(index)
+	if c == nil {
+		return ""
+	}
+
+	return c.DatabaseTypeName()
+}
+
+// ColumnTypeNullable returns whether the column with given index can be NULL.
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	c := r.column(index)
+	if c == nil {
+		return false, false
+	}
+
+	return !c.NotNull(), true
+}
+
+// ColumnTypeLength returns the length of the column with given index when it
+// is of a variable length type such as VARCHAR or VARBINARY.
+func (r *rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	c := r.column(index)
+	if c == nil || c.Type != xmysql.ColumnTypeBytes {
+		return 0, false
+	}
+
+	return int64(c.Length), true
+}
+
+// ColumnTypePrecisionScale returns the precision and scale of the column with
+// given index when it is of the DECIMAL type.
+func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	c := r.column(index)
+	if c == nil {
+		return 0, 0, false
+	}
+
+	return c.DecimalSize()
+}
+
+func (r *rows) column(index int) *xmysql.Column {
+	if r.xpresult == nil || index < 0 || index >= len(r.xpresult.Columns) {
+		return nil
+	}
+
+	return r.xpresult.Columns[index]
+}
@@ -6,10 +6,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/decimal"
+	"github.com/golistic/pxmysql/null"
 )
 
 func TestRows_Next(t *testing.T) {
@@ -37,3 +41,70 @@ func TestRows_Next(t *testing.T) {
 		xt.OK(t, db.QueryRowContext(ctx, stmt, 2).Scan(&tsNull))
 	})
 }
+
+func TestRows_ColumnTypes(t *testing.T) {
+	db, err := sql.Open("pxmysql", getTCPDSN("", ""))
+	xt.OK(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx := context.Background()
+
+	tbl := "test_column_types"
+	_, err = db.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS `%s`", tbl))
+	xt.OK(t, err)
+	_, err = db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE `%s` (id INT UNSIGNED NOT NULL, "+
+		"name VARCHAR(30) NULL, price DECIMAL(8,2) NOT NULL, ts DATETIME NULL)", tbl))
+	xt.OK(t, err)
+
+	// no rows are needed to get the column types
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT id, name, price, ts FROM `%s`", tbl))
+	xt.OK(t, err)
+	defer func() { _ = rows.Close() }()
+
+	types, err := rows.ColumnTypes()
+	xt.OK(t, err)
+	xt.Eq(t, 4, len(types))
+
+	t.Run("scan types", func(t *testing.T) {
+		xt.Eq(t, reflect.TypeOf(uint64(0)), types[0].ScanType())
+		xt.Eq(t, reflect.TypeOf(null.Of[string]{}), types[1].ScanType())
+		xt.Eq(t, reflect.TypeOf(decimal.Decimal{}), types[2].ScanType())
+		xt.Eq(t, reflect.TypeOf(null.Of[time.Time]{}), types[3].ScanType())
+	})
+
+	t.Run("database type names", func(t *testing.T) {
+		xt.Eq(t, "INT", types[0].DatabaseTypeName())
+		xt.Eq(t, "VARCHAR", types[1].DatabaseTypeName())
+		xt.Eq(t, "DECIMAL", types[2].DatabaseTypeName())
+		xt.Eq(t, "DATETIME", types[3].DatabaseTypeName())
+	})
+
+	t.Run("nullable", func(t *testing.T) {
+		nullable, ok := types[0].Nullable()
+		xt.Assert(t, ok)
+		xt.Assert(t, !nullable)
+
+		nullable, ok = types[1].Nullable()
+		xt.Assert(t, ok)
+		xt.Assert(t, nullable)
+	})
+
+	t.Run("length", func(t *testing.T) {
+		_, ok := types[0].Length()
+		xt.Assert(t, !ok)
+
+		length, ok := types[1].Length()
+		xt.Assert(t, ok)
+		xt.Assert(t, length >= 30)
+	})
+
+	t.Run("precision and scale", func(t *testing.T) {
+		precision, scale, ok := types[2].DecimalSize()
+		xt.Assert(t, ok)
+		xt.Eq(t, 8, precision)
+		xt.Eq(t, 2, scale)
+
+		_, _, ok = types[1].DecimalSize()
+		xt.Assert(t, !ok)
+	})
+}
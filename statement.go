@@ -36,9 +36,13 @@ func (s *statement) Close() error {
 	return nil
 }
 
-// NumInput returns the number of placeholders.
+// NumInput returns the number of placeholders. When the statement uses named
+// placeholders, this is the number of distinct names.
 func (s *statement) NumInput() int {
 	if s.prepared != nil {
+		if names := s.prepared.NamedPlaceholders(); len(names) > 0 {
+			return len(names)
+		}
 		return s.prepared.NumPlaceholders()
 	}
 
@@ -61,7 +65,7 @@ func (s *statement) ExecContext(ctx context.Context, args []driver.NamedValue) (
 	execArgs := make([]any, len(args))
 
 	for i, a := range args {
-		execArgs[i] = a.Value
+		execArgs[i] = a
 	}
 
 	execResult, err := s.prepared.Execute(ctx, execArgs...)
@@ -101,10 +105,6 @@ func (s *statement) QueryContext(ctx context.Context, args []driver.NamedValue)
 		return nil, handleError(err)
 	}
 
-	if len(execResult.Rows) == 0 {
-		return &rows{}, nil
-	}
-
 	r := &rows{
 		xpresult: execResult,
 	}
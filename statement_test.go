@@ -16,6 +16,8 @@ import (
 
 	"github.com/golistic/xgo/xt"
 
+	"github.com/golistic/pxmysql"
+
 	"github.com/golistic/pxmysql/mysqlerrors"
 )
 
@@ -143,4 +145,224 @@ func TestStatement_QueryContext(t *testing.T) {
 		xt.KO(t, err)
 		xt.Assert(t, errors.Is(err, sql.ErrNoRows))
 	})
+
+	t.Run("named arguments", func(t *testing.T) {
+		db, err := sql.Open("pxmysql", getTCPDSN("", ""))
+		xt.OK(t, err)
+		defer func() { _ = db.Close() }()
+
+		var id int
+		var name string
+		var again int
+		xt.OK(t, db.QueryRow("SELECT :id, @name, :id",
+			sql.Named("id", 7), sql.Named("name", "Gopher")).Scan(&id, &name, &again))
+		xt.Eq(t, 7, id)
+		xt.Eq(t, "Gopher", name)
+		xt.Eq(t, 7, again)
+
+		t.Run("prepared", func(t *testing.T) {
+			stmt, err := db.Prepare("SELECT :id, :id + 1")
+			xt.OK(t, err)
+			defer func() { _ = stmt.Close() }()
+
+			var next int
+			xt.OK(t, stmt.QueryRow(sql.Named("id", 3)).Scan(&id, &next))
+			xt.Eq(t, 3, id)
+			xt.Eq(t, 4, next)
+		})
+
+		t.Run("missing argument", func(t *testing.T) {
+			err := db.QueryRow("SELECT :id, :name", sql.Named("id", 1)).Scan(&id, &name)
+			xt.KO(t, err)
+			xt.Assert(t, strings.Contains(err.Error(), "missing argument for named placeholder 'name'"), err.Error())
+		})
+	})
+
+	t.Run("expand slices", func(t *testing.T) {
+		db, err := sql.Open("pxmysql", getTCPDSN("", "")+"&expandSlices=true")
+		xt.OK(t, err)
+		defer func() { _ = db.Close() }()
+
+		var found, notFound bool
+		xt.OK(t, db.QueryRow("SELECT 2 IN (?), 'b' IN (?)",
+			[]int{1, 2}, []string{"a", "c"}).Scan(&found, &notFound))
+		xt.Assert(t, found)
+		xt.Assert(t, !notFound)
+
+		xt.OK(t, db.QueryRow("SELECT :n IN (:ids)",
+			sql.Named("n", 4), sql.Named("ids", []int{3, 4})).Scan(&found))
+		xt.Assert(t, found)
+
+		err = db.QueryRow("SELECT 1 IN (?)", []int{}).Scan(&found)
+		xt.KO(t, err)
+		xt.Assert(t, strings.Contains(err.Error(), "cannot expand empty slice"), err.Error())
+	})
+
+	t.Run("interpolate parameters", func(t *testing.T) {
+		db, err := sql.Open("pxmysql", getTCPDSN("", "")+"&interpolateParams=true")
+		xt.OK(t, err)
+		defer func() { _ = db.Close() }()
+
+		needle := "ICkdie93kdOw"
+		stmt := "/* " + needle + " */ SELECT ?, ?, ?, COUNT(*) FROM performance_schema.prepared_statements_instances " +
+			"WHERE SQL_TEXT LIKE CONCAT('%', ?, '%')"
+
+		var s string
+		var i int
+		var d time.Time
+		var count int
+		xt.OK(t, db.QueryRow(stmt, `it's \ "quoted"`, 42, time.Date(2023, 9, 1, 13, 4, 5, 0, time.UTC),
+			needle).Scan(&s, &i, &d, &count))
+		xt.Eq(t, `it's \ "quoted"`, s)
+		xt.Eq(t, 42, i)
+		xt.Eq(t, 0, count) // not using server-side prepared statement
+
+		t.Run("NO_BACKSLASH_ESCAPES", func(t *testing.T) {
+			conn, err := db.Conn(context.Background())
+			xt.OK(t, err)
+			defer func() { _ = conn.Close() }()
+
+			_, err = conn.ExecContext(context.Background(),
+				"SET SESSION sql_mode = CONCAT(@@sql_mode, ',NO_BACKSLASH_ESCAPES')")
+			xt.OK(t, err)
+
+			xt.OK(t, conn.QueryRowContext(context.Background(), "SELECT ?", `it's C:\`).Scan(&s))
+			xt.Eq(t, `it's C:\`, s)
+		})
+	})
+
+	t.Run("cache prepared statements", func(t *testing.T) {
+		db, err := sql.Open("pxmysql", getTCPDSN("", "")+"&stmtCacheSize=2")
+		xt.OK(t, err)
+		defer func() { _ = db.Close() }()
+
+		ctx := context.Background()
+		conn, err := db.Conn(ctx)
+		xt.OK(t, err)
+		defer func() { _ = conn.Close() }()
+
+		stats := func() pxmysql.StmtCacheStats {
+			var s pxmysql.StmtCacheStats
+			xt.OK(t, conn.Raw(func(driverConn any) error {
+				s = driverConn.(interface{ StmtCacheStats() pxmysql.StmtCacheStats }).StmtCacheStats()
+				return nil
+			}))
+			return s
+		}
+
+		var n int
+		for i := 0; i < 3; i++ {
+			xt.OK(t, conn.QueryRowContext(ctx, "SELECT ?", i).Scan(&n))
+			xt.Eq(t, i, n)
+		}
+		xt.Eq(t, pxmysql.StmtCacheStats{Size: 2, Len: 1, Hits: 2, Misses: 1}, stats())
+
+		xt.OK(t, conn.QueryRowContext(ctx, "SELECT ? + 1", 1).Scan(&n))
+		xt.OK(t, conn.QueryRowContext(ctx, "SELECT ? + 2", 1).Scan(&n))
+		xt.Eq(t, pxmysql.StmtCacheStats{Size: 2, Len: 2, Hits: 2, Misses: 3, Evictions: 1}, stats())
+
+		var count int
+		xt.OK(t, conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM performance_schema.prepared_statements_instances "+
+			"WHERE OWNER_THREAD_ID = (SELECT THREAD_ID FROM performance_schema.threads "+
+			"WHERE PROCESSLIST_ID = CONNECTION_ID())").Scan(&count))
+		xt.Eq(t, 2, count) // evicted statement was deallocated
+	})
+
+	t.Run("cached prepared statements are keyed by SQL mode and schema", func(t *testing.T) {
+		db, err := sql.Open("pxmysql", getTCPDSN("", "")+"&stmtCacheSize=4")
+		xt.OK(t, err)
+		defer func() { _ = db.Close() }()
+
+		ctx := context.Background()
+		conn, err := db.Conn(ctx)
+		xt.OK(t, err)
+		defer func() { _ = conn.Close() }()
+
+		misses := func() uint64 {
+			var s pxmysql.StmtCacheStats
+			xt.OK(t, conn.Raw(func(driverConn any) error {
+				s = driverConn.(interface{ StmtCacheStats() pxmysql.StmtCacheStats }).StmtCacheStats()
+				return nil
+			}))
+			return s.Misses
+		}
+
+		var n int
+		xt.OK(t, conn.QueryRowContext(ctx, "SELECT ?", 1).Scan(&n))
+		xt.Eq(t, 1, misses())
+
+		_, err = conn.ExecContext(ctx, "USE mysql")
+		xt.OK(t, err)
+		xt.Eq(t, 1, misses()) // USE cannot be prepared and is executed as is
+
+		var schema string
+		xt.OK(t, conn.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&schema))
+		xt.Eq(t, "mysql", schema)
+
+		xt.OK(t, conn.QueryRowContext(ctx, "SELECT ?", 1).Scan(&n))
+		xt.Eq(t, 3, misses())
+
+		_, err = conn.ExecContext(ctx, "SET /* mode */ SESSION sql_mode = 'ANSI_QUOTES'")
+		xt.OK(t, err)
+		xt.OK(t, conn.QueryRowContext(ctx, "SELECT ?", 1).Scan(&n))
+		xt.Eq(t, 5, misses())
+	})
+
+	t.Run("cached prepared statement failing to execute is removed", func(t *testing.T) {
+		db, err := sql.Open("pxmysql", getTCPDSN("", "")+"&stmtCacheSize=4")
+		xt.OK(t, err)
+		defer func() { _ = db.Close() }()
+
+		ctx := context.Background()
+		conn, err := db.Conn(ctx)
+		xt.OK(t, err)
+		defer func() { _ = conn.Close() }()
+
+		stats := func() pxmysql.StmtCacheStats {
+			var s pxmysql.StmtCacheStats
+			xt.OK(t, conn.Raw(func(driverConn any) error {
+				s = driverConn.(interface{ StmtCacheStats() pxmysql.StmtCacheStats }).StmtCacheStats()
+				return nil
+			}))
+			return s
+		}
+
+		_, err = conn.ExecContext(ctx, "CREATE TEMPORARY TABLE stmt_cache_failing (a INT)")
+		xt.OK(t, err)
+
+		var n int
+		q := "SELECT COUNT(*) FROM stmt_cache_failing WHERE a = ?"
+		xt.OK(t, conn.QueryRowContext(ctx, q, 1).Scan(&n))
+		xt.Eq(t, pxmysql.StmtCacheStats{Size: 4, Len: 1, Misses: 1}, stats())
+
+		_, err = conn.ExecContext(ctx, "DROP TEMPORARY TABLE stmt_cache_failing")
+		xt.OK(t, err)
+
+		xt.KO(t, conn.QueryRowContext(ctx, q, 1).Scan(&n))
+		xt.Eq(t, pxmysql.StmtCacheStats{Size: 4, Len: 0, Hits: 1, Misses: 1}, stats())
+
+		_, err = conn.ExecContext(ctx, "CREATE TEMPORARY TABLE stmt_cache_failing (a INT)")
+		xt.OK(t, err)
+		xt.OK(t, conn.QueryRowContext(ctx, q, 1).Scan(&n))
+		xt.Eq(t, pxmysql.StmtCacheStats{Size: 4, Len: 1, Hits: 1, Misses: 2}, stats())
+	})
+
+	t.Run("resetting session clears cached prepared statements", func(t *testing.T) {
+		db, err := sql.Open("pxmysql", getTCPDSN("", "")+"&stmtCacheSize=4&resetSession=true")
+		xt.OK(t, err)
+		defer func() { _ = db.Close() }()
+		db.SetMaxOpenConns(1)
+
+		ctx := context.Background()
+
+		_, err = db.ExecContext(ctx, "SET @v = 1")
+		xt.OK(t, err)
+
+		var v sql.NullInt64
+		xt.OK(t, db.QueryRowContext(ctx, "SELECT @v + ?", 1).Scan(&v))
+		xt.Assert(t, !v.Valid) // user variable is gone
+
+		xt.OK(t, db.QueryRowContext(ctx, "SELECT @v + ?", 1).Scan(&v))
+		xt.Assert(t, !v.Valid) // statement prepared again after reset
+	})
 }
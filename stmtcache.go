@@ -0,0 +1,133 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package pxmysql
+
+import (
+	"container/list"
+	"context"
+	"sync/atomic"
+
+	"github.com/golistic/pxmysql/xmysql"
+)
+
+// StmtCacheStats holds statistics of the prepared statement cache of
+// a connection.
+type StmtCacheStats struct {
+	// Size is the maximum number of cached prepared statements.
+	Size int
+	// Len is the number of cached prepared statements.
+	Len int
+	// Hits is the number of times a cached prepared statement was used.
+	Hits uint64
+	// Misses is the number of times a statement had to be prepared.
+	Misses uint64
+	// Evictions is the number of prepared statements removed to make room.
+	Evictions uint64
+}
+
+// stmtCache is a least recently used (LRU) cache of prepared statements
+// keyed by the query. It is not safe for concurrent use, except for
+// retrieving the statistics.
+type stmtCache struct {
+	size  int
+	items map[string]*list.Element
+	order *list.List // front is most recently used
+	epoch uint64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+	length    atomic.Int64
+}
+
+type cachedStmt struct {
+	key      string
+	prepared *xmysql.Prepared
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:  size,
+		items: map[string]*list.Element{},
+		order: list.New(),
+	}
+}
+
+// get returns the prepared statement cached using key. When epoch differs from
+// the epoch of the cached statements, the session was reset or reopened and
+// the cache is cleared.
+func (c *stmtCache) get(key string, epoch uint64) (*xmysql.Prepared, bool) {
+	if epoch != c.epoch {
+		c.clear()
+		c.epoch = epoch
+	}
+
+	e, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	c.order.MoveToFront(e)
+	return e.Value.(*cachedStmt).prepared, true
+}
+
+// put stores prepared using key. When the cache is full, the least recently
+// used prepared statement is removed and deallocated.
+func (c *stmtCache) put(key string, prepared *xmysql.Prepared) {
+	if _, ok := c.items[key]; ok {
+		return
+	}
+
+	for c.order.Len() >= c.size {
+		e := c.order.Back()
+		item := e.Value.(*cachedStmt)
+		c.order.Remove(e)
+		delete(c.items, item.key)
+		c.evictions.Add(1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		_ = item.prepared.Deallocate(ctx)
+		cancel()
+	}
+
+	c.items[key] = c.order.PushFront(&cachedStmt{key: key, prepared: prepared})
+	c.length.Store(int64(c.order.Len()))
+}
+
+// remove removes and deallocates the prepared statement cached using key, for
+// example, because executing it failed and its server-side handle might be
+// gone.
+func (c *stmtCache) remove(key string) {
+	e, ok := c.items[key]
+	if !ok {
+		return
+	}
+
+	item := e.Value.(*cachedStmt)
+	c.order.Remove(e)
+	delete(c.items, key)
+	c.length.Store(int64(c.order.Len()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	_ = item.prepared.Deallocate(ctx)
+	cancel()
+}
+
+// clear removes all prepared statements without deallocating them.
+func (c *stmtCache) clear() {
+	c.items = map[string]*list.Element{}
+	c.order.Init()
+	c.length.Store(0)
+}
+
+func (c *stmtCache) stats() StmtCacheStats {
+	return StmtCacheStats{
+		Size:      c.size,
+		Len:       int(c.length.Load()),
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package pxmysql
+
+import (
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/xmysql"
+)
+
+func TestStmtCache(t *testing.T) {
+	t.Run("least recently used is evicted", func(t *testing.T) {
+		c := newStmtCache(2)
+		c.put("a", &xmysql.Prepared{})
+		c.put("b", &xmysql.Prepared{})
+
+		_, ok := c.get("a", 0)
+		xt.Assert(t, ok)
+
+		c.put("c", &xmysql.Prepared{})
+		_, ok = c.get("b", 0)
+		xt.Assert(t, !ok)
+		xt.Eq(t, StmtCacheStats{Size: 2, Len: 2, Hits: 1, Misses: 1, Evictions: 1}, c.stats())
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		c := newStmtCache(2)
+		c.put("a", &xmysql.Prepared{})
+		c.put("b", &xmysql.Prepared{})
+
+		c.remove("a")
+		c.remove("unknown")
+
+		_, ok := c.get("a", 0)
+		xt.Assert(t, !ok)
+		_, ok = c.get("b", 0)
+		xt.Assert(t, ok)
+		xt.Eq(t, StmtCacheStats{Size: 2, Len: 1, Hits: 1, Misses: 1}, c.stats())
+	})
+
+	t.Run("epoch change clears", func(t *testing.T) {
+		c := newStmtCache(2)
+		c.put("a", &xmysql.Prepared{})
+
+		_, ok := c.get("a", 1)
+		xt.Assert(t, !ok)
+		xt.Eq(t, 0, c.stats().Len)
+	})
+}
@@ -6,32 +6,206 @@ import (
 	"crypto/sha1"
 	"crypto/sha256"
 	"fmt"
+	"sort"
+	"sync"
 )
 
-type authn struct {
-	username  string
-	password  string
-	schema    string
-	challenge []byte
+// AuthCredentials holds what an Authenticator uses to authenticate.
+type AuthCredentials struct {
+	Username string
+	Password string
+	Schema   string
+	// TLS is whether the connection uses TLS.
+	TLS bool
+}
+
+// Authenticator implements an authentication mechanism of the X Protocol.
+// Authentication starts by sending the data returned by Start. Each challenge
+// sent by the server is answered with the data returned by Continue, until
+// the server accepts or refuses the credentials.
+// A new Authenticator is used for each attempt, so it can keep state between
+// rounds.
+type Authenticator interface {
+	// Name returns the name of the mechanism as reported by the server
+	// using the authentication.mechanisms capability, for example, MYSQL41.
+	Name() AuthMethodType
+	// Start returns the data sent when starting authentication, which can
+	// be nil. When an error is returned, nothing was sent to the server.
+	Start(creds AuthCredentials) ([]byte, error)
+	// Continue returns the response to the challenge sent by the server.
+	Continue(creds AuthCredentials, challenge []byte) ([]byte, error)
+}
+
+var (
+	authenticatorsMu sync.RWMutex
+	authenticators   = map[AuthMethodType]func() Authenticator{
+		AuthMethodPlain:        func() Authenticator { return authPlain{} },
+		AuthMethodMySQL41:      func() Authenticator { return authMySQL41{} },
+		AuthMethodSHA256Memory: func() Authenticator { return authSHA256Memory{} },
+	}
+)
+
+// autoAuthMethods are the mechanisms tried first, in this order, when using
+// AuthMethodAuto. Other registered mechanisms are tried after these.
+var autoAuthMethods = []AuthMethodType{AuthMethodPlain, AuthMethodMySQL41, AuthMethodSHA256Memory}
+
+// RegisterAuthenticator makes the authentication mechanism returned by newFunc
+// available using its name. A mechanism registered earlier using the same name,
+// including the ones provided by this package, is replaced.
+func RegisterAuthenticator(newFunc func() Authenticator) error {
+	if newFunc == nil {
+		return fmt.Errorf("authenticator cannot be nil")
+	}
+
+	a := newFunc()
+	if a == nil {
+		return fmt.Errorf("authenticator cannot be nil")
+	}
+
+	name := a.Name()
+	switch name {
+	case "":
+		return fmt.Errorf("authenticator name cannot be empty")
+	case AuthMethodAuto:
+		return fmt.Errorf("authenticator name %s is reserved", name)
+	}
+
+	authenticatorsMu.Lock()
+	defer authenticatorsMu.Unlock()
+	authenticators[name] = newFunc
+
+	return nil
+}
+
+// newAuthenticator returns a new Authenticator for the mechanism name.
+func newAuthenticator(name AuthMethodType) (Authenticator, bool) {
+	authenticatorsMu.RLock()
+	defer authenticatorsMu.RUnlock()
+
+	newFunc, ok := authenticators[name]
+	if !ok {
+		return nil, false
+	}
+	return newFunc(), true
+}
+
+// registeredAuthMethods returns the names of the registered mechanisms
+// sorted by name.
+func registeredAuthMethods() []AuthMethodType {
+	authenticatorsMu.RLock()
+	defer authenticatorsMu.RUnlock()
+
+	names := make([]AuthMethodType, 0, len(authenticators))
+	for name := range authenticators {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	return names
+}
+
+// negotiateAuthMethods returns the mechanisms to try, in order, when using
+// AuthMethodAuto. Only mechanisms reported by the server are returned; when
+// the server does not report any, all registered mechanisms are returned.
+func negotiateAuthMethods(serverMechanisms []string) []AuthMethodType {
+	registered := AuthMethodTypes(registeredAuthMethods())
+
+	var preferred AuthMethodTypes
+	for _, m := range autoAuthMethods {
+		if registered.Has(m) {
+			preferred = append(preferred, m)
+		}
+	}
+	for _, m := range registered {
+		if !preferred.Has(m) {
+			preferred = append(preferred, m)
+		}
+	}
+
+	if len(serverMechanisms) == 0 {
+		return preferred
+	}
+
+	var methods []AuthMethodType
+	for _, m := range preferred {
+		for _, sm := range serverMechanisms {
+			if string(m) == sm {
+				methods = append(methods, m)
+				break
+			}
+		}
+	}
+
+	return methods
+}
+
+// authPlain implements the PLAIN mechanism which sends the password as is,
+// and therefore is only supported when the connection uses TLS.
+type authPlain struct{}
+
+func (authPlain) Name() AuthMethodType {
+	return AuthMethodPlain
+}
+
+func (authPlain) Start(creds AuthCredentials) ([]byte, error) {
+	if !creds.TLS {
+		return nil, fmt.Errorf("plain text authentication only supported over TLS")
+	}
+	return authMySQLPlain(creds), nil
+}
+
+func (authPlain) Continue(AuthCredentials, []byte) ([]byte, error) {
+	return nil, fmt.Errorf("unexpected challenge using %s authentication", AuthMethodPlain)
+}
+
+// authMySQL41 implements the MYSQL41 mechanism (mysql_native_password).
+type authMySQL41 struct{}
+
+func (authMySQL41) Name() AuthMethodType {
+	return AuthMethodMySQL41
+}
+
+func (authMySQL41) Start(AuthCredentials) ([]byte, error) {
+	return nil, nil
+}
+
+func (authMySQL41) Continue(creds AuthCredentials, challenge []byte) ([]byte, error) {
+	return authMySQL41Data(creds, challenge)
+}
+
+// authSHA256Memory implements the SHA256_MEMORY mechanism, which uses the
+// password hashes cached by the server (caching_sha2_password).
+type authSHA256Memory struct{}
+
+func (authSHA256Memory) Name() AuthMethodType {
+	return AuthMethodSHA256Memory
+}
+
+func (authSHA256Memory) Start(AuthCredentials) ([]byte, error) {
+	return nil, nil
+}
+
+func (authSHA256Memory) Continue(creds AuthCredentials, challenge []byte) ([]byte, error) {
+	return authSHA256Data(creds, challenge)
 }
 
 // authSHA256Data prepares authentication data to be sent with the AuthenticateContinue
 // message using SHA256. Username and scrambled password are returned as hex.
 // See: https://dev.mysql.com/doc/internals/en/x-protocol-authentication-authentication.html.
-func authSHA256Data(an authn) ([]byte, error) {
-	if len(an.challenge) != authChallengeLen {
-		return nil, fmt.Errorf("authentication challenge must be 20 bytes (was %d)", len(an.challenge))
+func authSHA256Data(creds AuthCredentials, challenge []byte) ([]byte, error) {
+	if len(challenge) != authChallengeLen {
+		return nil, fmt.Errorf("authentication challenge must be 20 bytes (was %d)", len(challenge))
 	}
 
 	var scramble string
-	if an.password != "" {
+	if creds.Password != "" {
 		// hex(sha256(password) XOR sha256(challenge + sha256(sha256(password))))
-		h1 := sha256.Sum256([]byte(an.password))
+		h1 := sha256.Sum256([]byte(creds.Password))
 		hh1 := sha256.Sum256(h1[:])
 
 		hr := sha256.New()
 		hr.Write(hh1[:])
-		hr.Write(an.challenge)
+		hr.Write(challenge)
 		h2 := hr.Sum(nil)
 
 		for i := range h2 {
@@ -40,26 +214,26 @@ func authSHA256Data(an authn) ([]byte, error) {
 		scramble = fmt.Sprintf("%x", h1)
 	}
 
-	return []byte(fmt.Sprintf("%s\x00%s\x00%s", an.schema, an.username, scramble)), nil
+	return []byte(fmt.Sprintf("%s\x00%s\x00%s", creds.Schema, creds.Username, scramble)), nil
 }
 
 // authMYSQL41Data prepares authentication data to be sent with the AuthenticateContinue
 // message using SHA1 (also known as mysql_native_password). Username and scrambled password
 // are returned as hex.
 // See: https://dev.mysql.com/doc/internals/en/x-protocol-authentication-authentication.html.
-func authMySQL41Data(an authn) ([]byte, error) {
-	if len(an.challenge) != authChallengeLen {
-		return nil, fmt.Errorf("authentication challenge must be 20 bytes (was %d)", len(an.challenge))
+func authMySQL41Data(creds AuthCredentials, challenge []byte) ([]byte, error) {
+	if len(challenge) != authChallengeLen {
+		return nil, fmt.Errorf("authentication challenge must be 20 bytes (was %d)", len(challenge))
 	}
 
 	var scramble string
-	if an.password != "" {
+	if creds.Password != "" {
 		// hex(sha1(password) XOR sha1(challenge + sha1(sha1(password))))
-		h1 := sha1.Sum([]byte(an.password))
+		h1 := sha1.Sum([]byte(creds.Password))
 		hh1 := sha1.Sum(h1[:])
 
 		hr := sha1.New()
-		hr.Write(an.challenge)
+		hr.Write(challenge)
 		hr.Write(hh1[:])
 		h2 := hr.Sum(nil)
 
@@ -70,12 +244,12 @@ func authMySQL41Data(an authn) ([]byte, error) {
 		scramble = fmt.Sprintf("*%x", h1)
 	}
 
-	return []byte(fmt.Sprintf("%s\x00%s\x00%s", an.schema, an.username, scramble)), nil
+	return []byte(fmt.Sprintf("%s\x00%s\x00%s", creds.Schema, creds.Username, scramble)), nil
 }
 
 // authMySQLPlain prepares authentication data to be sent in plain text. This is only
 // supported when connection is encrypted (TLS)
 // See: https://dev.mysql.com/doc/internals/en/x-protocol-authentication-authentication.html.
-func authMySQLPlain(an authn) ([]byte, error) {
-	return []byte(fmt.Sprintf("%s\x00%s\x00%s", an.schema, an.username, an.password)), nil
+func authMySQLPlain(creds AuthCredentials) []byte {
+	return []byte(fmt.Sprintf("%s\x00%s\x00%s", creds.Schema, creds.Username, creds.Password))
 }
@@ -3,16 +3,52 @@
 package xmysql
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"sort"
+
+	"github.com/golistic/xgo/xstrings"
 
 	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxconnection"
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxdatatypes"
 	"github.com/golistic/pxmysql/xmysql/internal/network"
+	"github.com/golistic/pxmysql/xmysql/xproto"
 )
 
+// capabilitiesNegotiated are the capabilities which are set by the session
+// using their own configuration, and cannot be set otherwise.
+var capabilitiesNegotiated = []string{"tls", "compression", "session_connect_attrs"}
+
 // ServerCapabilities holds the capabilities returned by the server.
 type ServerCapabilities struct {
-	TLS            bool
+	// TLS is whether the connection uses TLS.
+	TLS bool
+	// TLSSupported is whether the server can switch the connection to TLS.
+	TLSSupported   bool
 	AuthMechanisms []string
+	// CompressionAlgorithms are the compression algorithms supported by
+	// the server. It is empty when the server does not support compression.
+	CompressionAlgorithms []string
+	// DocFormats is the format in which documents are sent, for example, "text".
+	DocFormats string
+	// NodeType is the kind of server, for example, "mysql".
+	NodeType string
+	// PluginVersion is the version of the X Plugin, if reported.
+	PluginVersion string
+	// ClientPwdExpireOK is whether the client can authenticate using an
+	// expired password (capability client.pwd_expire_ok).
+	ClientPwdExpireOK bool
+	// ClientInteractive is whether the session is interactive, which makes
+	// the server use interactive_timeout (capability client.interactive).
+	ClientInteractive bool
+	// SessionConnectAttrs is whether the server reported support for
+	// connection attributes (capability session_connect_attrs).
+	SessionConnectAttrs bool
+	// All contains every capability reported by the server using its name.
+	// Scalars are Go values like bool, string, int64, uint64, or float64;
+	// arrays are []any, and objects are map[string]any.
+	All map[string]any
 }
 
 // NewServerCapabilitiesFromMessage instantiates a new ServerCapabilities object
@@ -23,19 +59,171 @@ func NewServerCapabilitiesFromMessage(msg *network.ServerMessage) (*ServerCapabi
 		return nil, fmt.Errorf("message was not mysqlxconnection.Capabilities")
 	}
 
-	sc := &ServerCapabilities{}
+	return newServerCapabilities(capabilities), nil
+}
+
+func newServerCapabilities(capabilities *mysqlxconnection.Capabilities) *ServerCapabilities {
+	sc := &ServerCapabilities{
+		All: map[string]any{},
+	}
 
 	for _, c := range capabilities.Capabilities {
+		sc.All[c.GetName()] = anyValue(c.GetValue())
+
 		switch c.GetName() {
 		case "tls":
+			sc.TLSSupported = true
 			sc.TLS = c.Value.Scalar.GetVBool()
 		case "authentication.mechanisms":
 			sc.AuthMechanisms = []string{}
 			for _, m := range c.Value.Array.Value {
 				sc.AuthMechanisms = append(sc.AuthMechanisms, string(m.Scalar.GetVString().GetValue()))
 			}
+		case "compression":
+			for _, f := range c.Value.GetObj().GetFld() {
+				if f.GetKey() != "algorithm" {
+					continue
+				}
+				for _, a := range f.GetValue().GetArray().GetValue() {
+					sc.CompressionAlgorithms = append(sc.CompressionAlgorithms,
+						string(a.GetScalar().GetVString().GetValue()))
+				}
+			}
+		case "doc.formats":
+			sc.DocFormats = string(c.GetValue().GetScalar().GetVString().GetValue())
+		case "node_type":
+			sc.NodeType = string(c.GetValue().GetScalar().GetVString().GetValue())
+		case "plugin.version":
+			sc.PluginVersion = string(c.GetValue().GetScalar().GetVString().GetValue())
+		case "client.pwd_expire_ok":
+			sc.ClientPwdExpireOK = c.GetValue().GetScalar().GetVBool()
+		case "client.interactive":
+			sc.ClientInteractive = c.GetValue().GetScalar().GetVBool()
+		case "session_connect_attrs":
+			sc.SessionConnectAttrs = true
+		}
+	}
+
+	return sc
+}
+
+// anyValue returns the Go value of a.
+func anyValue(a *mysqlxdatatypes.Any) any {
+	switch a.GetType() {
+	case mysqlxdatatypes.Any_SCALAR:
+		s := a.GetScalar()
+		switch s.GetType() {
+		case mysqlxdatatypes.Scalar_V_SINT:
+			return s.GetVSignedInt()
+		case mysqlxdatatypes.Scalar_V_UINT:
+			return s.GetVUnsignedInt()
+		case mysqlxdatatypes.Scalar_V_DOUBLE:
+			return s.GetVDouble()
+		case mysqlxdatatypes.Scalar_V_FLOAT:
+			return float64(s.GetVFloat())
+		case mysqlxdatatypes.Scalar_V_BOOL:
+			return s.GetVBool()
+		case mysqlxdatatypes.Scalar_V_STRING:
+			return string(s.GetVString().GetValue())
+		case mysqlxdatatypes.Scalar_V_OCTETS:
+			return s.GetVOctets().GetValue()
+		default:
+			return nil
+		}
+	case mysqlxdatatypes.Any_ARRAY:
+		values := make([]any, len(a.GetArray().GetValue()))
+		for i, v := range a.GetArray().GetValue() {
+			values[i] = anyValue(v)
+		}
+		return values
+	case mysqlxdatatypes.Any_OBJECT:
+		fields := map[string]any{}
+		for _, f := range a.GetObj().GetFld() {
+			fields[f.GetKey()] = anyValue(f.GetValue())
+		}
+		return fields
+	default:
+		return nil
+	}
+}
+
+// capabilityValue returns value as Any to be used for setting a capability.
+func capabilityValue(value any) (*mysqlxdatatypes.Any, error) {
+	switch v := value.(type) {
+	case *mysqlxdatatypes.Any:
+		return v, nil
+	case bool:
+		return xproto.Bool(v), nil
+	case string:
+		return xproto.String(v), nil
+	case int:
+		return xproto.SignedInt(v), nil
+	case int64:
+		return xproto.SignedInt(v), nil
+	case uint:
+		return xproto.UnsignedInt(v), nil
+	case uint64:
+		return xproto.UnsignedInt(v), nil
+	case map[string]string:
+		return xproto.StringObject(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported capability value type %T", value)
+	}
+}
+
+// checkCapabilities returns an error when one of capabilities cannot be set.
+func checkCapabilities(capabilities map[string]any) error {
+	for name, value := range capabilities {
+		if xstrings.SliceHas(capabilitiesNegotiated, name) {
+			return fmt.Errorf("capability %s is set using its own configuration", name)
+		}
+		if _, err := capabilityValue(value); err != nil {
+			return fmt.Errorf("capability %s (%w)", name, err)
+		}
+	}
+
+	return nil
+}
+
+// SetCapability sets the capability name to value. The value is, for example,
+// a bool, string, integer, or map[string]string. Capabilities like
+// client.pwd_expire_ok must be set before authenticating, which is done using
+// ConnectConfig.Capabilities.
+func (ses *Session) SetCapability(ctx context.Context, name string, value any) error {
+	if xstrings.SliceHas(capabilitiesNegotiated, name) {
+		return fmt.Errorf("capability %s is set using its own configuration", name)
+	}
+
+	v, err := capabilityValue(value)
+	if err != nil {
+		return err
+	}
+
+	return ses.setCapability(ctx, name, v)
+}
+
+// setConfiguredCapabilities sets the capabilities of the configuration
+// ordered by name.
+func (ses *Session) setConfiguredCapabilities(ctx context.Context) error {
+	capabilities := maps.Clone(ses.config.Capabilities)
+	if ses.config.AllowExpiredPassword {
+		if capabilities == nil {
+			capabilities = map[string]any{}
+		}
+		capabilities["client.pwd_expire_ok"] = true
+	}
+
+	names := make([]string, 0, len(capabilities))
+	for name := range capabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ses.SetCapability(ctx, name, capabilities[name]); err != nil {
+			return err
 		}
 	}
 
-	return sc, nil
+	return nil
 }
@@ -0,0 +1,224 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxresultset"
+)
+
+// ColumnType is the type of column as reported by the X Plugin. Note that
+// this is not the MySQL data type: for example, all signed integer types are
+// reported as ColumnTypeSInt.
+type ColumnType int32
+
+const (
+	ColumnTypeSInt     = ColumnType(mysqlxresultset.ColumnMetaData_SINT)
+	ColumnTypeUInt     = ColumnType(mysqlxresultset.ColumnMetaData_UINT)
+	ColumnTypeDouble   = ColumnType(mysqlxresultset.ColumnMetaData_DOUBLE)
+	ColumnTypeFloat    = ColumnType(mysqlxresultset.ColumnMetaData_FLOAT)
+	ColumnTypeBytes    = ColumnType(mysqlxresultset.ColumnMetaData_BYTES)
+	ColumnTypeTime     = ColumnType(mysqlxresultset.ColumnMetaData_TIME)
+	ColumnTypeDatetime = ColumnType(mysqlxresultset.ColumnMetaData_DATETIME)
+	ColumnTypeSet      = ColumnType(mysqlxresultset.ColumnMetaData_SET)
+	ColumnTypeEnum     = ColumnType(mysqlxresultset.ColumnMetaData_ENUM)
+	ColumnTypeBit      = ColumnType(mysqlxresultset.ColumnMetaData_BIT)
+	ColumnTypeDecimal  = ColumnType(mysqlxresultset.ColumnMetaData_DECIMAL)
+)
+
+// String returns the name of the column type, for example "SINT".
+func (t ColumnType) String() string {
+	return mysqlxresultset.ColumnMetaData_FieldType(t).String()
+}
+
+// Content types further specify the data of a column. Their meaning
+// depends on the column type.
+const (
+	ContentTypeGeometry uint32 = 0x0001 // ColumnTypeBytes: WKB encoding
+	ContentTypeJSON     uint32 = 0x0002 // ColumnTypeBytes: text encoding
+	ContentTypeXML      uint32 = 0x0003 // ColumnTypeBytes: text encoding
+	ContentTypeDate     uint32 = 0x0001 // ColumnTypeDatetime: only date part
+	ContentTypeDatetime uint32 = 0x0002 // ColumnTypeDatetime: date and time part
+)
+
+const (
+	// zerofill (UINT), unsigned (DOUBLE, FLOAT, DECIMAL), rightpad (BYTES), timestamp (DATETIME)
+	flagTypeSpecific  = 0x0001
+	flagNotNull       = 0x0010
+	flagPrimaryKey    = 0x0020
+	flagUniqueKey     = 0x0040
+	flagMultipleKey   = 0x0080
+	flagAutoIncrement = 0x0100
+)
+
+// Column holds the metadata of a column within a result.
+type Column struct {
+	Name             string
+	OriginalName     string
+	Table            string
+	OriginalTable    string
+	Schema           string
+	Catalog          string
+	Type             ColumnType
+	Length           uint32
+	FractionalDigits uint32
+	Collation        uint64
+	ContentType      uint32
+	Flags            uint32
+}
+
+// newColumn instantiates a Column using the metadata sent by the X Plugin.
+func newColumn(m *mysqlxresultset.ColumnMetaData) *Column {
+	return &Column{
+		Name:             string(m.GetName()),
+		OriginalName:     string(m.GetOriginalName()),
+		Table:            string(m.GetTable()),
+		OriginalTable:    string(m.GetOriginalTable()),
+		Schema:           string(m.GetSchema()),
+		Catalog:          string(m.GetCatalog()),
+		Type:             ColumnType(m.GetType()),
+		Length:           m.GetLength(),
+		FractionalDigits: m.GetFractionalDigits(),
+		Collation:        m.GetCollation(),
+		ContentType:      m.GetContentType(),
+		Flags:            m.GetFlags(),
+	}
+}
+
+// NotNull returns whether the column cannot contain NULL.
+func (c *Column) NotNull() bool {
+	return c.Flags&flagNotNull > 0
+}
+
+// PrimaryKey returns whether the column is (part of) the primary key.
+func (c *Column) PrimaryKey() bool {
+	return c.Flags&flagPrimaryKey > 0
+}
+
+// Unique returns whether the column is (part of) a unique key.
+func (c *Column) Unique() bool {
+	return c.Flags&flagUniqueKey > 0
+}
+
+// MultipleKey returns whether the column is part of a non-unique key.
+func (c *Column) MultipleKey() bool {
+	return c.Flags&flagMultipleKey > 0
+}
+
+// AutoIncrement returns whether the column has the AUTO_INCREMENT attribute.
+func (c *Column) AutoIncrement() bool {
+	return c.Flags&flagAutoIncrement > 0
+}
+
+// Unsigned returns whether the column is of an unsigned numeric type.
+func (c *Column) Unsigned() bool {
+	switch c.Type {
+	case ColumnTypeUInt:
+		return true
+	case ColumnTypeDouble, ColumnTypeFloat, ColumnTypeDecimal:
+		return c.Flags&flagTypeSpecific > 0
+	default:
+		return false
+	}
+}
+
+// Zerofill returns whether the column is an integer which values are
+// padded with zeros.
+func (c *Column) Zerofill() bool {
+	return c.Type == ColumnTypeUInt && c.Flags&flagTypeSpecific > 0
+}
+
+// hasCharacterSet returns whether the column stores text, which is
+// when it has a (supported) collation.
+func (c *Column) hasCharacterSet() bool {
+	_, ok := collationIDs[c.Collation]
+	return ok
+}
+
+// DatabaseTypeName returns the MySQL data type name of the column, for
+// example "VARCHAR" or "BIGINT". The X Plugin does not report the exact data
+// type, so it is derived from the column type, flags, length and content type.
+// For example, TEXT and BLOB columns are reported as VARCHAR and VARBINARY.
+func (c *Column) DatabaseTypeName() string {
+	switch c.Type {
+	case ColumnTypeSInt, ColumnTypeUInt:
+		// length is the display width, which includes the sign for signed integers
+		width := c.Length
+		if c.Type == ColumnTypeSInt && width > 0 {
+			width--
+		}
+		switch {
+		case width <= 3:
+			return "TINYINT"
+		case width <= 5:
+			return "SMALLINT"
+		case width <= 8:
+			return "MEDIUMINT"
+		case width <= 10:
+			return "INT"
+		default:
+			return "BIGINT"
+		}
+	case ColumnTypeDouble:
+		return "DOUBLE"
+	case ColumnTypeFloat:
+		return "FLOAT"
+	case ColumnTypeDecimal:
+		return "DECIMAL"
+	case ColumnTypeBytes:
+		switch c.ContentType {
+		case ContentTypeJSON:
+			return "JSON"
+		case ContentTypeGeometry:
+			return "GEOMETRY"
+		}
+		rightPad := c.Flags&flagTypeSpecific > 0
+		switch {
+		case c.hasCharacterSet() && rightPad:
+			return "CHAR"
+		case c.hasCharacterSet():
+			return "VARCHAR"
+		case rightPad:
+			return "BINARY"
+		default:
+			return "VARBINARY"
+		}
+	case ColumnTypeTime:
+		return "TIME"
+	case ColumnTypeDatetime:
+		switch {
+		case c.ContentType == ContentTypeDate:
+			return "DATE"
+		case c.Flags&flagTypeSpecific > 0:
+			return "TIMESTAMP"
+		default:
+			return "DATETIME"
+		}
+	case ColumnTypeSet:
+		return "SET"
+	case ColumnTypeEnum:
+		return "ENUM"
+	case ColumnTypeBit:
+		return "BIT"
+	default:
+		return ""
+	}
+}
+
+// DecimalSize returns the precision and scale of DECIMAL columns. The ok return
+// value is false for other column types.
+func (c *Column) DecimalSize() (precision, scale int64, ok bool) {
+	if c.Type != ColumnTypeDecimal {
+		return 0, 0, false
+	}
+
+	// length is the display length which includes the decimal point and sign
+	precision = int64(c.Length)
+	if c.FractionalDigits > 0 {
+		precision--
+	}
+	if !c.Unsigned() {
+		precision--
+	}
+
+	return precision, int64(c.FractionalDigits), true
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/internal/xxt"
+	"github.com/golistic/pxmysql/xmysql"
+)
+
+func TestColumn_Flags(t *testing.T) {
+	t.Run("unsigned and zerofill", func(t *testing.T) {
+		c := &xmysql.Column{Type: xmysql.ColumnTypeUInt, Flags: 0x0001}
+		xt.Assert(t, c.Unsigned())
+		xt.Assert(t, c.Zerofill())
+
+		c = &xmysql.Column{Type: xmysql.ColumnTypeDouble, Flags: 0x0001}
+		xt.Assert(t, c.Unsigned())
+		xt.Assert(t, !c.Zerofill())
+
+		c = &xmysql.Column{Type: xmysql.ColumnTypeBytes, Flags: 0x0001}
+		xt.Assert(t, !c.Unsigned())
+	})
+
+	t.Run("database type name", func(t *testing.T) {
+		var cases = []struct {
+			column *xmysql.Column
+			exp    string
+		}{
+			{column: &xmysql.Column{Type: xmysql.ColumnTypeSInt, Length: 4}, exp: "TINYINT"},
+			{column: &xmysql.Column{Type: xmysql.ColumnTypeUInt, Length: 10}, exp: "INT"},
+			{column: &xmysql.Column{Type: xmysql.ColumnTypeSInt, Length: 20}, exp: "BIGINT"},
+			{column: &xmysql.Column{Type: xmysql.ColumnTypeBytes, ContentType: xmysql.ContentTypeJSON}, exp: "JSON"},
+			{column: &xmysql.Column{Type: xmysql.ColumnTypeBytes, Collation: 255}, exp: "VARCHAR"},
+			{column: &xmysql.Column{Type: xmysql.ColumnTypeBytes, Flags: 0x0001}, exp: "BINARY"},
+			{column: &xmysql.Column{Type: xmysql.ColumnTypeDatetime, ContentType: xmysql.ContentTypeDate}, exp: "DATE"},
+			{column: &xmysql.Column{Type: xmysql.ColumnTypeDatetime, Flags: 0x0001}, exp: "TIMESTAMP"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.exp, func(t *testing.T) {
+				xt.Eq(t, c.exp, c.column.DatabaseTypeName())
+			})
+		}
+	})
+
+	t.Run("decimal size", func(t *testing.T) {
+		c := &xmysql.Column{Type: xmysql.ColumnTypeDecimal, Length: 10, FractionalDigits: 2}
+		precision, scale, ok := c.DecimalSize()
+		xt.Assert(t, ok)
+		xt.Eq(t, 8, precision)
+		xt.Eq(t, 2, scale)
+
+		_, _, ok = (&xmysql.Column{Type: xmysql.ColumnTypeDouble}).DecimalSize()
+		xt.Assert(t, !ok)
+	})
+
+	t.Run("metadata from server", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Address:  testContext.XPluginAddr,
+			Username: xxt.UserNative,
+			Schema:   testSchema,
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+
+		_, err = ses.ExecuteStatement(context.Background(), "DROP TABLE IF EXISTS column_meta_01")
+		xt.OK(t, err)
+		_, err = ses.ExecuteStatement(context.Background(),
+			"CREATE TABLE column_meta_01 (id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY, "+
+				"code VARCHAR(20) NOT NULL UNIQUE, price DECIMAL(8,2), doc JSON)")
+		xt.OK(t, err)
+
+		res, err := ses.ExecuteStatement(context.Background(),
+			"SELECT id, code AS c, price, doc FROM column_meta_01")
+		xt.OK(t, err)
+		xt.Eq(t, 4, len(res.Columns))
+
+		id := res.Columns[0]
+		xt.Eq(t, xmysql.ColumnTypeUInt, id.Type)
+		xt.Assert(t, id.PrimaryKey())
+		xt.Assert(t, id.AutoIncrement())
+		xt.Assert(t, id.NotNull())
+		xt.Assert(t, id.Unsigned())
+		xt.Eq(t, "column_meta_01", id.Table)
+		xt.Eq(t, testSchema, id.Schema)
+
+		code := res.Columns[1]
+		xt.Eq(t, "c", code.Name)
+		xt.Eq(t, "code", code.OriginalName)
+		xt.Assert(t, code.Unique())
+		xt.Assert(t, xmysql.IsSupportedCollation(code.Collation))
+
+		price := res.Columns[2]
+		xt.Eq(t, xmysql.ColumnTypeDecimal, price.Type)
+		xt.Eq(t, 2, price.FractionalDigits)
+		xt.Assert(t, !price.NotNull())
+
+		xt.Eq(t, xmysql.ContentTypeJSON, res.Columns[3].ContentType)
+	})
+}
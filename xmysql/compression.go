@@ -0,0 +1,163 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golistic/xgo/xstrings"
+
+	"github.com/golistic/pxmysql/xmysql/internal/network"
+	"github.com/golistic/pxmysql/xmysql/xproto"
+)
+
+// CompressionMode defines whether messages exchanged with the server are
+// compressed.
+type CompressionMode string
+
+const (
+	// CompressionDisabled does not compress messages.
+	CompressionDisabled CompressionMode = "DISABLED"
+	// CompressionPreferred compresses messages when the server supports one
+	// of the configured algorithms.
+	CompressionPreferred CompressionMode = "PREFERRED"
+	// CompressionRequired compresses messages, and fails opening the session
+	// when the server does not support one of the configured algorithms.
+	CompressionRequired CompressionMode = "REQUIRED"
+)
+
+// Compression algorithms defined by the X Protocol.
+const (
+	CompressionDeflateStream = network.CompressionDeflateStream
+	CompressionLZ4Message    = "lz4_message"
+	CompressionZstdStream    = "zstd_stream"
+)
+
+var compressionAlgorithms = []string{
+	CompressionDeflateStream, CompressionLZ4Message, CompressionZstdStream,
+}
+
+// ParseCompressionMode returns the compression mode s, which is case-insensitive.
+func ParseCompressionMode(s string) (CompressionMode, error) {
+	mode := CompressionMode(strings.ToUpper(s))
+	switch mode {
+	case CompressionDisabled, CompressionPreferred, CompressionRequired:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported compression mode '%s'", s)
+	}
+}
+
+// ParseCompressionAlgorithm returns the name of the compression algorithm s,
+// which is case-insensitive. The short names deflate, lz4, and zstd are
+// accepted as well.
+func ParseCompressionAlgorithm(s string) (string, error) {
+	name := strings.ToLower(s)
+	switch name {
+	case "deflate":
+		name = CompressionDeflateStream
+	case "lz4":
+		name = CompressionLZ4Message
+	case "zstd":
+		name = CompressionZstdStream
+	}
+
+	if !xstrings.SliceHas(compressionAlgorithms, name) {
+		return "", fmt.Errorf("unsupported compression algorithm '%s'", s)
+	}
+
+	return name, nil
+}
+
+// SupportedCompressionAlgorithms returns the compression algorithms which this
+// package implements.
+func SupportedCompressionAlgorithms() []string {
+	return []string{CompressionDeflateStream}
+}
+
+// normalizeCompression normalizes the compression mode and algorithms of cfg,
+// for example, the short name deflate becomes deflate_stream. Returns an
+// error when the mode or one of the algorithms is not valid.
+func normalizeCompression(cfg *ConnectConfig) error {
+	if cfg.Compression != "" {
+		mode, err := ParseCompressionMode(string(cfg.Compression))
+		if err != nil {
+			return err
+		}
+		cfg.Compression = mode
+	}
+
+	cfg.CompressionAlgorithms = slices.Clone(cfg.CompressionAlgorithms)
+	for i, a := range cfg.CompressionAlgorithms {
+		name, err := ParseCompressionAlgorithm(a)
+		if err != nil {
+			return err
+		}
+		cfg.CompressionAlgorithms[i] = name
+	}
+
+	return nil
+}
+
+// agreedCompressionAlgorithm returns the first configured compression algorithm
+// which is supported by both client and server. Returns an empty string
+// when there is none.
+func (ses *Session) agreedCompressionAlgorithm() string {
+	algorithms := ses.config.CompressionAlgorithms
+	if len(algorithms) == 0 {
+		algorithms = SupportedCompressionAlgorithms()
+	}
+
+	for _, a := range algorithms {
+		if xstrings.SliceHas(SupportedCompressionAlgorithms(), a) &&
+			xstrings.SliceHas(ses.serverCapabilities.CompressionAlgorithms, a) {
+			return a
+		}
+	}
+
+	return ""
+}
+
+// startCompression negotiates compression with the server, and when agreed,
+// wraps the connection so that messages are compressed.
+func (ses *Session) startCompression(ctx context.Context) error {
+	mode := ses.config.Compression
+	if mode == "" || mode == CompressionDisabled {
+		return nil
+	}
+
+	algorithm := ses.agreedCompressionAlgorithm()
+	if algorithm == "" {
+		if mode == CompressionPreferred {
+			return nil
+		}
+		return fmt.Errorf("no compression algorithm supported by both client and server")
+	}
+
+	if err := ses.setCapability(ctx, "compression", xproto.Object(
+		xproto.ObjectField("algorithm", algorithm),
+		xproto.ObjectField("server_combine_mixed_messages", true),
+	)); err != nil {
+		return err
+	}
+
+	conn, err := network.NewCompressedConn(ses.conn, algorithm)
+	if err != nil {
+		return err
+	}
+	ses.conn = conn
+
+	return nil
+}
+
+// CompressionAlgorithm returns the algorithm used to compress messages, or an
+// empty string when messages are not compressed.
+func (ses *Session) CompressionAlgorithm() string {
+	if c, ok := ses.conn.(*network.CompressedConn); ok {
+		return c.Algorithm()
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql_test
+
+import (
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/xmysql"
+)
+
+func TestParseCompressionMode(t *testing.T) {
+	t.Run("valid modes", func(t *testing.T) {
+		var cases = map[string]xmysql.CompressionMode{
+			"disabled":  xmysql.CompressionDisabled,
+			"Preferred": xmysql.CompressionPreferred,
+			"REQUIRED":  xmysql.CompressionRequired,
+		}
+
+		for s, exp := range cases {
+			t.Run(s, func(t *testing.T) {
+				mode, err := xmysql.ParseCompressionMode(s)
+				xt.OK(t, err)
+				xt.Eq(t, exp, mode)
+			})
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := xmysql.ParseCompressionMode("zip")
+		xt.KO(t, err)
+		xt.Eq(t, "unsupported compression mode 'zip'", err.Error())
+	})
+}
+
+func TestParseCompressionAlgorithm(t *testing.T) {
+	t.Run("valid algorithms", func(t *testing.T) {
+		var cases = map[string]string{
+			"deflate_stream": xmysql.CompressionDeflateStream,
+			"Deflate":        xmysql.CompressionDeflateStream,
+			"lz4":            xmysql.CompressionLZ4Message,
+			"ZSTD_STREAM":    xmysql.CompressionZstdStream,
+		}
+
+		for s, exp := range cases {
+			t.Run(s, func(t *testing.T) {
+				name, err := xmysql.ParseCompressionAlgorithm(s)
+				xt.OK(t, err)
+				xt.Eq(t, exp, name)
+			})
+		}
+	})
+
+	t.Run("invalid algorithm", func(t *testing.T) {
+		_, err := xmysql.ParseCompressionAlgorithm("gzip")
+		xt.KO(t, err)
+		xt.Eq(t, "unsupported compression algorithm 'gzip'", err.Error())
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golistic/pxmysql/mysqlerrors"
+	"github.com/golistic/pxmysql/xmysql/xproto"
+)
+
+// ClientName is the name of this client as sent to the server using the
+// _client_name connection attribute.
+const ClientName = "pxmysql"
+
+const modulePath = "github.com/golistic/pxmysql"
+
+// errCodeCapabilityNotFound is the error returned by MySQL servers which
+// do not know about a capability (ER_X_CAPABILITY_NOT_FOUND).
+const errCodeCapabilityNotFound = 5002
+
+// maxConnectionAttributeName is the maximum length of the name of a
+// connection attribute accepted by the server.
+const maxConnectionAttributeName = 32
+
+var clientVersion = sync.OnceValue(func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath && info.Main.Version != "" {
+			return info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+})
+
+// DefaultConnectionAttributes returns the connection attributes sent to the
+// server unless disabled. It contains _client_name, _client_version, _os,
+// _platform, _pid, and program_name.
+func DefaultConnectionAttributes() map[string]string {
+	return map[string]string{
+		"_client_name":    ClientName,
+		"_client_version": clientVersion(),
+		"_os":             runtime.GOOS,
+		"_platform":       runtime.GOARCH,
+		"_pid":            strconv.Itoa(os.Getpid()),
+		"program_name":    filepath.Base(os.Args[0]),
+	}
+}
+
+// checkConnectionAttributes returns an error when the name of one of the
+// attributes is not valid.
+func checkConnectionAttributes(attrs map[string]string) error {
+	for name := range attrs {
+		switch {
+		case name == "":
+			return fmt.Errorf("connection attribute name cannot be empty")
+		case strings.HasPrefix(name, "_"):
+			return fmt.Errorf("connection attribute name %s is reserved", name)
+		case len(name) > maxConnectionAttributeName:
+			return fmt.Errorf("connection attribute name %s too long (max %d)", name, maxConnectionAttributeName)
+		}
+	}
+
+	return nil
+}
+
+// connectionAttributes returns the default connection attributes with those
+// of the configuration added.
+func (ses *Session) connectionAttributes() map[string]string {
+	attrs := DefaultConnectionAttributes()
+	for k, v := range ses.config.ConnectionAttributes {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// sendConnectionAttributes sends the connection attributes to the server
+// using the session_connect_attrs capability. MySQL servers which do not
+// support this capability are ignored.
+func (ses *Session) sendConnectionAttributes(ctx context.Context) error {
+	if ses.config.DisableConnectionAttributes {
+		return nil
+	}
+
+	err := ses.setCapability(ctx, "session_connect_attrs", xproto.StringObject(ses.connectionAttributes()))
+
+	var myErr *mysqlerrors.Error
+	if errors.As(err, &myErr) && myErr.Code == errCodeCapabilityNotFound {
+		return nil
+	}
+
+	return err
+}
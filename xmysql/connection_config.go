@@ -3,20 +3,144 @@
 package xmysql
 
 import (
+	"context"
+	"crypto/tls"
+	"maps"
+	"slices"
+	"time"
+
 	"github.com/golistic/xgo/xstrings"
 )
 
+// MaxHostPriority is the highest priority a host can have.
+const MaxHostPriority = 100
+
+// Host is one of the MySQL servers a session can connect to.
+type Host struct {
+	// Address is the TCP address of the X Plugin, for example, 10.0.0.2:33060.
+	Address string
+	// Priority is between 0 and MaxHostPriority; hosts with a higher
+	// priority are tried first.
+	Priority int
+}
+
 // ConnectConfig manages the configuration of a connection to a MySQL server.
 type ConnectConfig struct {
-	Address             string
-	UnixSockAddr        string
-	Username            string
-	Password            *string
-	Schema              string
-	UseTLS              bool
-	AuthMethod          AuthMethodType
-	TLSServerCACertPath string `envVar:"PXMYSQL_CA_CERT"`
-	TimeZoneName        string
+	Address             string         `envVar:"PXMYSQL_ADDRESS"`
+	UnixSockAddr        string         `envVar:"PXMYSQL_UNIX_SOCKET"`
+	Username            string         `envVar:"PXMYSQL_USER"`
+	Password            *string        `envVar:"PXMYSQL_PASSWORD"`
+	Schema              string         `envVar:"PXMYSQL_SCHEMA"`
+	UseTLS              bool           `envVar:"PXMYSQL_USE_TLS"`
+	AuthMethod          AuthMethodType `envVar:"PXMYSQL_AUTH_METHOD"`
+	TLSServerCACertPath string         `envVar:"PXMYSQL_CA_CERT"`
+	TimeZoneName        string         `envVar:"PXMYSQL_TIME_ZONE"`
+
+	// Hosts are the servers tried when opening the session, instead of
+	// Address. Hosts with a higher priority are tried first, and hosts
+	// with equal priority in the given order, or randomly when RandomizeHosts
+	// is set. Each attempt has its own ConnectTimeout. Only TCP is supported.
+	// Hosts are given using the environment in the form read by ParseHosts.
+	Hosts []Host `envVar:"PXMYSQL_HOSTS"`
+	// RandomizeHosts makes hosts with equal priority tried in random order.
+	RandomizeHosts bool `envVar:"PXMYSQL_RANDOMIZE_HOSTS"`
+
+	// SSLMode defines whether and how TLS is used. When empty, UseTLS
+	// is used (see EffectiveSSLMode).
+	SSLMode SSLMode `envVar:"PXMYSQL_SSL_MODE"`
+
+	// TLSUseSystemCAs makes the system's CAs trusted in addition to the CA
+	// certificate in TLSServerCACertPath. Without TLSServerCACertPath, the
+	// system's CAs are always used.
+	TLSUseSystemCAs bool `envVar:"PXMYSQL_TLS_SYSTEM_CAS"`
+
+	// TLSClientCertPath and TLSClientKeyPath are the paths to the files
+	// containing the client certificate and its private key, which are
+	// needed for accounts created with, for example, REQUIRE X509.
+	TLSClientCertPath string `envVar:"PXMYSQL_TLS_CERT"`
+	TLSClientKeyPath  string `envVar:"PXMYSQL_TLS_KEY"`
+	// TLSMinVersion and TLSMaxVersion are the minimum and maximum TLS
+	// versions, for example tls.VersionTLS13. Zero means Go's defaults.
+	TLSMinVersion uint16 `envVar:"PXMYSQL_TLS_MIN_VERSION"`
+	TLSMaxVersion uint16 `envVar:"PXMYSQL_TLS_MAX_VERSION"`
+	// TLSCipherSuites are the IDs of the enabled cipher suites for TLS 1.2;
+	// when empty, Go's defaults are used.
+	TLSCipherSuites []uint16 `envVar:"PXMYSQL_TLS_CIPHER_SUITES"`
+	// TLSCRLPath is the path to the file containing the certificate revocation
+	// list (PEM or DER) against which the server's certificates are checked.
+	// The chain of the server's certificate must be verifiable, using the CA
+	// certificate or the system's CAs, so that the signature of the list is
+	// checked using its issuer. A list past its next update is refused.
+	TLSCRLPath string `envVar:"PXMYSQL_TLS_CRL"`
+	// TLSConfig is used, instead of the other TLS fields, when switching to TLS.
+	// It is cloned, and when ServerName is empty, it is set to the host.
+	TLSConfig *tls.Config
+	// GetTLSConfig is called each time the session is opened, and the returned
+	// configuration is used like TLSConfig. This is useful for rotating
+	// client certificates. It takes precedence over TLSConfig.
+	GetTLSConfig func(ctx context.Context) (*tls.Config, error)
+
+	// Collation is the name of the collation set for the connection
+	// after authenticating. When empty, the server default is used.
+	Collation string `envVar:"PXMYSQL_COLLATION"`
+
+	// ConnectTimeout is the maximum time to establish the network connection.
+	// Zero means no timeout other than the one of the context.
+	ConnectTimeout time.Duration `envVar:"PXMYSQL_CONNECT_TIMEOUT"`
+	// ReadTimeout is the maximum time to wait reading a message from the server
+	// when the context has no deadline. When zero, 10 seconds is used.
+	ReadTimeout time.Duration `envVar:"PXMYSQL_READ_TIMEOUT"`
+	// WriteTimeout is the maximum time to write a message to the server when
+	// the context has no deadline. Zero means no timeout.
+	WriteTimeout time.Duration `envVar:"PXMYSQL_WRITE_TIMEOUT"`
+
+	// MaxAllowedPacket is the maximum size in bytes of a message sent to the
+	// server. When zero, or larger than the mysqlx_max_allowed_packet of the
+	// server, the server's value is used.
+	MaxAllowedPacket int `envVar:"PXMYSQL_MAX_ALLOWED_PACKET"`
+
+	// ConnectionAttributes are sent to the server together with the default
+	// attributes, such as _client_name and program_name, and show up in
+	// performance_schema.session_connect_attrs. Names starting with an
+	// underscore are reserved; program_name can be overridden.
+	ConnectionAttributes map[string]string `envVar:"PXMYSQL_CONNECTION_ATTRIBUTES"`
+	// DisableConnectionAttributes prevents sending any connection attributes.
+	DisableConnectionAttributes bool `envVar:"PXMYSQL_DISABLE_CONNECTION_ATTRIBUTES"`
+
+	// Compression defines whether messages are compressed. When empty,
+	// compression is disabled.
+	Compression CompressionMode `envVar:"PXMYSQL_COMPRESSION"`
+	// CompressionAlgorithms are the algorithms, in order of preference, which
+	// are negotiated with the server. Algorithms not implemented, like
+	// lz4_message, are skipped. When empty, deflate_stream is used.
+	CompressionAlgorithms []string `envVar:"PXMYSQL_COMPRESSION_ALGORITHMS"`
+
+	// Capabilities are set, in order of their names, when opening the session
+	// before authenticating. For example, client.pwd_expire_ok set to true lets
+	// accounts with an expired password connect to change it.
+	Capabilities map[string]any
+
+	// AllowExpiredPassword sets the capability client.pwd_expire_ok so that
+	// accounts with an expired password can connect. Such sessions can only
+	// change the password using Session.ChangePassword.
+	AllowExpiredPassword bool `envVar:"PXMYSQL_ALLOW_EXPIRED_PASSWORD"`
+
+	// CredentialsProvider is called each time the session is opened, and the
+	// returned username and password are used instead of Username and Password.
+	// When the returned username is empty, Username is used. This is useful for
+	// passwords which are rotated, or short-lived tokens.
+	CredentialsProvider func(ctx context.Context) (user, password string, err error)
+
+	// UseNullOf makes results use the generic null.Of[T] for columns
+	// which can be NULL instead of the named types such as null.String.
+	UseNullOf bool `envVar:"PXMYSQL_USE_NULL_OF"`
+
+	// ExpandSlices makes a slice argument bound to a single placeholder expand
+	// to as many placeholders as the slice has elements, for example, for
+	// use with `IN (?)`. Only SQL statements executed using
+	// Session.ExecuteStatement, and the SQL driver, are covered; the X DevAPI
+	// CRUD operations of this package do not bind placeholders.
+	ExpandSlices bool `envVar:"PXMYSQL_EXPAND_SLICES"`
 }
 
 // DefaultConnectConfig is the default configuration used if none is provided
@@ -40,9 +164,36 @@ func (cfg *ConnectConfig) Clone() *ConnectConfig {
 		Password:            nil,
 		Schema:              cfg.Schema,
 		UseTLS:              cfg.UseTLS,
+		SSLMode:             cfg.SSLMode,
+		TLSUseSystemCAs:     cfg.TLSUseSystemCAs,
+		TLSClientCertPath:   cfg.TLSClientCertPath,
+		TLSClientKeyPath:    cfg.TLSClientKeyPath,
+		TLSMinVersion:       cfg.TLSMinVersion,
+		TLSMaxVersion:       cfg.TLSMaxVersion,
+		TLSCipherSuites:     slices.Clone(cfg.TLSCipherSuites),
+		TLSCRLPath:          cfg.TLSCRLPath,
+		TLSConfig:           cfg.TLSConfig,
+		GetTLSConfig:        cfg.GetTLSConfig,
 		AuthMethod:          cfg.AuthMethod,
 		TLSServerCACertPath: cfg.TLSServerCACertPath,
 		TimeZoneName:        cfg.TimeZoneName,
+		Collation:           cfg.Collation,
+		ConnectTimeout:      cfg.ConnectTimeout,
+		ReadTimeout:         cfg.ReadTimeout,
+		WriteTimeout:        cfg.WriteTimeout,
+		MaxAllowedPacket:    cfg.MaxAllowedPacket,
+		UseNullOf:           cfg.UseNullOf,
+		ExpandSlices:        cfg.ExpandSlices,
+
+		ConnectionAttributes:        maps.Clone(cfg.ConnectionAttributes),
+		DisableConnectionAttributes: cfg.DisableConnectionAttributes,
+		Compression:                 cfg.Compression,
+		CompressionAlgorithms:       slices.Clone(cfg.CompressionAlgorithms),
+		Capabilities:                maps.Clone(cfg.Capabilities),
+		AllowExpiredPassword:        cfg.AllowExpiredPassword,
+		CredentialsProvider:         cfg.CredentialsProvider,
+		Hosts:                       slices.Clone(cfg.Hosts),
+		RandomizeHosts:              cfg.RandomizeHosts,
 	}
 }
 
@@ -29,12 +29,12 @@ func (a AuthMethodTypes) Has(m AuthMethodType) bool {
 
 var defaultAuthMethods = []AuthMethodType{AuthMethodMySQL41, AuthMethodSHA256Memory}
 
-var supportedAuthMethods = AuthMethodTypes{AuthMethodSHA256Memory, AuthMethodMySQL41, AuthMethodPlain, AuthMethodAuto}
-
 func DefaultAuthMethods() []AuthMethodType {
 	return defaultAuthMethods
 }
 
+// SupportedAuthMethods returns the registered authentication mechanisms sorted
+// by name, followed by AuthMethodAuto.
 func SupportedAuthMethods() AuthMethodTypes {
-	return supportedAuthMethods
+	return append(registeredAuthMethods(), AuthMethodAuto)
 }
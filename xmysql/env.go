@@ -0,0 +1,200 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables given by the
+// envVar tags of the ConnectConfig fields, for example, PXMYSQL_ADDRESS.
+const DefaultEnvPrefix = "PXMYSQL"
+
+// envFileSuffix is appended to the name of an environment variable to get
+// the name of the variable holding the path of a file containing the value.
+const envFileSuffix = "_FILE"
+
+// ConfigFromEnv returns the default configuration updated using environment
+// variables. See ConnectConfig.ApplyEnv.
+func ConfigFromEnv(prefix string) (*ConnectConfig, error) {
+	cfg := DefaultConnectConfig.Clone()
+	cfg.SetPassword("")
+
+	if err := cfg.ApplyEnv(prefix); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ApplyEnv updates cfg using environment variables, which are named using
+// the envVar tags of the ConnectConfig fields, for example, PXMYSQL_USER.
+// When prefix is not empty, it replaces PXMYSQL, so that prefix MYAPP
+// reads MYAPP_USER. Fields for which no variable is set keep their value,
+// which allows the environment to override, for example, a configuration
+// read using ParseURI.
+//
+// Instead of the value, the path of a file containing it can be given by
+// appending _FILE to the name, for example, PXMYSQL_PASSWORD_FILE. Values are
+// parsed like the options of mysqlx:// URIs: booleans using strconv.ParseBool,
+// durations like 5s, TLS versions like TLSv1.3, and lists separated by commas.
+// Connection attributes are given as key:value pairs, and hosts in the form
+// read by ParseHosts.
+func (cfg *ConnectConfig) ApplyEnv(prefix string) error {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("envVar")
+		if tag == "" {
+			continue
+		}
+		name := prefix + strings.TrimPrefix(tag, DefaultEnvPrefix)
+
+		value, ok, err := lookupEnv(name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setEnvValue(v.Field(i).Addr().Interface(), value); err != nil {
+			return fmt.Errorf("invalid value for environment variable %s (%w)", name, err)
+		}
+
+		switch t.Field(i).Name {
+		case "TimeZoneName":
+			if _, err := time.LoadLocation(value); err != nil {
+				return fmt.Errorf("invalid value for environment variable %s (%w)", name, err)
+			}
+		case "Collation":
+			if !IsSupportedCollation(value) {
+				return fmt.Errorf("invalid value for environment variable %s (unsupported collation '%s')",
+					name, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookupEnv returns the value of the environment variable name, or the
+// content of the file given by name with _FILE appended. It returns
+// an error when both are set.
+func lookupEnv(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+
+	path, okFile := os.LookupEnv(name + envFileSuffix)
+	if !okFile {
+		return value, ok, nil
+	}
+
+	if ok {
+		return "", false, fmt.Errorf("environment variables %s and %s cannot be used together",
+			name, name+envFileSuffix)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed reading file of environment variable %s (%w)",
+			name+envFileSuffix, err)
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), true, nil
+}
+
+// setEnvValue parses value and stores it in the field pointed to by p.
+func setEnvValue(p any, value string) error {
+	var err error
+
+	switch p := p.(type) {
+	case *string:
+		*p = value
+	case **string:
+		*p = &value
+	case *bool:
+		*p, err = strconv.ParseBool(value)
+	case *int:
+		var n int
+		if n, err = strconv.Atoi(value); err == nil && n < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+		*p = n
+	case *time.Duration:
+		var d time.Duration
+		if d, err = time.ParseDuration(value); err == nil && d < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+		*p = d
+	case *uint16: // TLS versions
+		*p, err = ParseTLSVersion(value)
+	case *[]uint16: // TLS cipher suites
+		*p = nil
+		for _, n := range envList(value) {
+			id, err := ParseTLSCipherSuite(n)
+			if err != nil {
+				return err
+			}
+			*p = append(*p, id)
+		}
+	case *[]string: // compression algorithms
+		*p = nil
+		for _, n := range envList(value) {
+			a, err := ParseCompressionAlgorithm(n)
+			if err != nil {
+				return err
+			}
+			*p = append(*p, a)
+		}
+	case *map[string]string: // connection attributes
+		attrs := map[string]string{}
+		for _, attr := range envList(value) {
+			k, v, ok := strings.Cut(attr, ":")
+			if !ok {
+				return fmt.Errorf("connection attribute must be key:value")
+			}
+			attrs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		if err := checkConnectionAttributes(attrs); err != nil {
+			return err
+		}
+		*p = attrs
+	case *SSLMode:
+		*p, err = ParseSSLMode(value)
+	case *AuthMethodType:
+		m := AuthMethodType(strings.ToUpper(value))
+		if !SupportedAuthMethods().Has(m) {
+			return fmt.Errorf("unsupported authentication method '%s'", value)
+		}
+		*p = m
+	case *CompressionMode:
+		*p, err = ParseCompressionMode(value)
+	case *[]Host:
+		*p, err = ParseHosts(value)
+	default:
+		return fmt.Errorf("unsupported type %T", p)
+	}
+
+	return err
+}
+
+// envList returns the elements of the comma separated list s.
+func envList(s string) []string {
+	var elements []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			elements = append(elements, e)
+		}
+	}
+	return elements
+}
@@ -2,6 +2,41 @@
 
 package xmysql
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golistic/pxmysql/mysqlerrors"
+)
 
 var ErrNotAvailable = fmt.Errorf("not available")
+
+// ErrPasswordExpired is returned when the password of the account has expired.
+// Use ConnectConfig.AllowExpiredPassword to connect, and Session.ChangePassword
+// to set a new password.
+var ErrPasswordExpired = fmt.Errorf("password expired")
+
+const (
+	// errCodeMustChangePassword is returned for statements executed using
+	// an expired password (ER_MUST_CHANGE_PASSWORD).
+	errCodeMustChangePassword = 1820
+	// errCodeMustChangePasswordLogin is returned when authenticating using an
+	// expired password without client.pwd_expire_ok (ER_MUST_CHANGE_PASSWORD_LOGIN).
+	errCodeMustChangePasswordLogin = 1862
+)
+
+// wrapServerError returns err wrapped with an error of this package when
+// the MySQL error needs special care, for example, ErrPasswordExpired.
+func wrapServerError(err error) error {
+	var myErr *mysqlerrors.Error
+	if !errors.As(err, &myErr) {
+		return err
+	}
+
+	switch myErr.Code {
+	case errCodeMustChangePassword, errCodeMustChangePasswordLogin:
+		return fmt.Errorf("%w (%w)", ErrPasswordExpired, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+)
+
+// normalizeAddress returns the TCP address addr using the default host
+// and port when these are missing.
+func normalizeAddress(addr string) string {
+	h, p, err := net.SplitHostPort(addr)
+	var addrErr *net.AddrError
+	if errors.As(err, &addrErr) {
+		h = addr // on error h is empty
+		p = DefaultPort
+	}
+	if h == "" {
+		h = DefaultHost
+	}
+
+	return net.JoinHostPort(h, p)
+}
+
+// normalizeHosts checks the configured hosts and normalizes their addresses.
+func (ses *Session) normalizeHosts() error {
+	if len(ses.config.Hosts) == 0 {
+		return nil
+	}
+
+	if ses.config.UnixSockAddr != "" {
+		return fmt.Errorf("hosts cannot be used together with a Unix socket")
+	}
+
+	hosts := make([]Host, len(ses.config.Hosts))
+	for i, h := range ses.config.Hosts {
+		if h.Priority < 0 || h.Priority > MaxHostPriority {
+			return fmt.Errorf("host priority must be between 0 and %d (was %d)", MaxHostPriority, h.Priority)
+		}
+		hosts[i] = Host{
+			Address:  normalizeAddress(h.Address),
+			Priority: h.Priority,
+		}
+	}
+	ses.config.Hosts = hosts
+
+	return nil
+}
+
+// orderedHosts returns the configured hosts in the order they are tried:
+// highest priority first. Hosts with equal priority keep the configured
+// order, unless RandomizeHosts is set.
+func (ses *Session) orderedHosts() []Host {
+	hosts := make([]Host, len(ses.config.Hosts))
+	copy(hosts, ses.config.Hosts)
+
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return hosts[i].Priority > hosts[j].Priority
+	})
+
+	if ses.config.RandomizeHosts {
+		for start := 0; start < len(hosts); {
+			end := start + 1
+			for end < len(hosts) && hosts[end].Priority == hosts[start].Priority {
+				end++
+			}
+			group := hosts[start:end]
+			rand.Shuffle(len(group), func(i, j int) {
+				group[i], group[j] = group[j], group[i]
+			})
+			start = end
+		}
+	}
+
+	return hosts
+}
+
+// openHosts opens the connection to the first configured host which
+// succeeds. The returned error reports the error of each host.
+func (ses *Session) openHosts(ctx context.Context) error {
+	var errs []error
+
+	for _, h := range ses.orderedHosts() {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		err := ses.openHost(ctx, h.Address)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s (%w)", h.Address, err))
+	}
+
+	ses.address = ""
+
+	return fmt.Errorf("failed connecting to any host (%w)", errors.Join(errs...))
+}
+
+// openHost opens the connection to the host at address. When ConnectTimeout
+// is set, it limits the whole attempt, including the handshake, so that a
+// host accepting connections but not responding does not stall the
+// remaining hosts. State of a previous attempt is discarded first.
+func (ses *Session) openHost(ctx context.Context, address string) error {
+	if ses.config.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ses.config.ConnectTimeout)
+		defer cancel()
+	}
+
+	ses.serverCapabilities = nil
+	ses.usedAuthMethod = ""
+	ses.passwordExpired = false
+	ses.maxAllowedPacket = 0
+
+	return ses.openAddress(ctx, "tcp", address)
+}
+
+// Address returns the address of the server the session connected to, which
+// is one of the configured hosts, the address, or the Unix socket.
+func (ses *Session) Address() string {
+	return ses.address
+}
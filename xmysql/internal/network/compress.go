@@ -0,0 +1,271 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package network
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlx"
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxconnection"
+)
+
+// CompressionDeflateStream is the name of the deflate_stream compression
+// algorithm, which is the only one implemented.
+const CompressionDeflateStream = "deflate_stream"
+
+// CompressionThreshold is the size in bytes from which messages sent to the
+// server are compressed. Smaller messages are sent as is.
+const CompressionThreshold = 1000
+
+// MaxUncompressedSize is the default maximum size in bytes of the messages
+// contained by a Compression message read from the server. It is the largest
+// value MySQL allows for mysqlx_max_allowed_packet.
+const MaxUncompressedSize = 1 << 30
+
+// compressor compresses and decompresses payloads of Compression messages.
+type compressor interface {
+	compress(frames []byte) ([]byte, error)
+	decompress(payload []byte, size int) ([]byte, error)
+}
+
+// CompressedConn wraps a network connection and compresses messages written,
+// and decompresses Compression messages read. Messages are read and written
+// using Read and Write like any other connection.
+type CompressedConn struct {
+	net.Conn
+	algorithm  string
+	compressor compressor
+	threshold  int
+	maxSize    uint64
+	wbuf       []byte
+	rbuf       []byte
+}
+
+var _ net.Conn = &CompressedConn{}
+
+// NewCompressedConn returns conn wrapped so that messages are compressed using
+// the algorithm. Returns an error when algorithm is not supported.
+func NewCompressedConn(conn net.Conn, algorithm string) (*CompressedConn, error) {
+	c := &CompressedConn{
+		Conn:      conn,
+		algorithm: algorithm,
+		threshold: CompressionThreshold,
+		maxSize:   MaxUncompressedSize,
+	}
+
+	switch algorithm {
+	case CompressionDeflateStream:
+		c.compressor = &deflateStream{}
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %s", algorithm)
+	}
+
+	return c, nil
+}
+
+// NetConn returns the wrapped connection.
+func (c *CompressedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// SetMaxUncompressedSize sets the maximum size in bytes of the messages
+// contained by a Compression message read from the server. Reading a
+// Compression message announcing a larger size fails without decompressing
+// it. When n is not positive, MaxUncompressedSize is used.
+func (c *CompressedConn) SetMaxUncompressedSize(n int) {
+	if n <= 0 {
+		n = MaxUncompressedSize
+	}
+	c.maxSize = uint64(n)
+}
+
+// Algorithm returns the name of the compression algorithm.
+func (c *CompressedConn) Algorithm() string {
+	return c.algorithm
+}
+
+// Write buffers b until it contains complete messages, which are then sent
+// to the server. Messages smaller than the threshold are not compressed.
+func (c *CompressedConn) Write(b []byte) (int, error) {
+	c.wbuf = append(c.wbuf, b...)
+
+	for len(c.wbuf) >= 4 {
+		size := 4 + int(binary.LittleEndian.Uint32(c.wbuf[:4]))
+		if len(c.wbuf) < size {
+			break
+		}
+
+		frame := c.wbuf[:size]
+		if err := c.writeFrame(frame); err != nil {
+			c.wbuf = nil
+			return 0, err
+		}
+		c.wbuf = c.wbuf[size:]
+	}
+
+	if len(c.wbuf) == 0 {
+		c.wbuf = nil
+	}
+
+	return len(b), nil
+}
+
+func (c *CompressedConn) writeFrame(frame []byte) error {
+	if len(frame) < c.threshold {
+		_, err := c.Conn.Write(frame)
+		return err
+	}
+
+	payload, err := c.compressor.compress(frame)
+	if err != nil {
+		return fmt.Errorf("failed compressing message (%w)", err)
+	}
+
+	b, err := proto.Marshal(&mysqlxconnection.Compression{
+		UncompressedSize: proto.Uint64(uint64(len(frame))),
+		ClientMessages:   mysqlx.ClientMessages_Type(frame[4]).Enum(),
+		Payload:          payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed marshalling protobuf message (%w)", err)
+	}
+
+	var header [5]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(b))+1)
+	header[4] = byte(mysqlx.ClientMessages_COMPRESSION)
+
+	_, err = c.Conn.Write(append(header[:], b...))
+	return err
+}
+
+// Read reads messages from the server into b. Compression messages are
+// decompressed, and the messages they contain are read instead.
+func (c *CompressedConn) Read(b []byte) (int, error) {
+	if len(c.rbuf) == 0 {
+		if err := c.readFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(b, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+
+	return n, nil
+}
+
+func (c *CompressedConn) readFrame() error {
+	var header [5]byte
+	if _, err := io.ReadFull(c.Conn, header[:]); err != nil {
+		return err
+	}
+
+	size := binary.LittleEndian.Uint32(header[:4])
+	if size == 0 {
+		return fmt.Errorf("invalid message size")
+	}
+
+	frame := make([]byte, 5+size-1)
+	copy(frame, header[:])
+	if _, err := io.ReadFull(c.Conn, frame[5:]); err != nil {
+		return err
+	}
+
+	if header[4] != byte(mysqlx.ServerMessages_COMPRESSION) {
+		c.rbuf = frame
+		return nil
+	}
+
+	msg := &mysqlxconnection.Compression{}
+	if err := UnmarshalPartial(frame[5:], msg); err != nil {
+		return fmt.Errorf("failed unmarshalling compressed message (%w)", err)
+	}
+
+	if size := msg.GetUncompressedSize(); size > c.maxSize {
+		return fmt.Errorf("uncompressed size of compressed message %d exceeds maximum %d", size, c.maxSize)
+	}
+
+	frames, err := c.compressor.decompress(msg.GetPayload(), int(msg.GetUncompressedSize()))
+	if err != nil {
+		return fmt.Errorf("failed decompressing message (%w)", err)
+	}
+	c.rbuf = frames
+
+	return nil
+}
+
+// deflateStream implements the deflate_stream algorithm: a single zlib stream
+// for each direction of the connection, flushed after each message.
+type deflateStream struct {
+	w    *zlib.Writer
+	wbuf bytes.Buffer
+	r    io.ReadCloser
+	rbuf streamBuffer
+}
+
+func (d *deflateStream) compress(frames []byte) ([]byte, error) {
+	if d.w == nil {
+		d.w = zlib.NewWriter(&d.wbuf)
+	}
+
+	d.wbuf.Reset()
+	if _, err := d.w.Write(frames); err != nil {
+		return nil, err
+	}
+	if err := d.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(d.wbuf.Bytes()), nil
+}
+
+func (d *deflateStream) decompress(payload []byte, size int) ([]byte, error) {
+	d.rbuf.data = append(d.rbuf.data, payload...)
+
+	if d.r == nil {
+		var err error
+		if d.r, err = zlib.NewReader(&d.rbuf); err != nil {
+			return nil, err
+		}
+	}
+
+	frames := make([]byte, size)
+	if _, err := io.ReadFull(d.r, frames); err != nil {
+		return nil, err
+	}
+
+	return frames, nil
+}
+
+// streamBuffer holds compressed data received so far. It implements
+// io.ByteReader so that the decompressor does not read ahead, which would
+// consume data of messages not yet received.
+type streamBuffer struct {
+	data []byte
+}
+
+func (s *streamBuffer) Read(b []byte) (int, error) {
+	if len(s.data) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(b, s.data)
+	s.data = s.data[n:]
+	return n, nil
+}
+
+func (s *streamBuffer) ReadByte() (byte, error) {
+	if len(s.data) == 0 {
+		return 0, io.EOF
+	}
+
+	b := s.data[0]
+	s.data = s.data[1:]
+	return b, nil
+}
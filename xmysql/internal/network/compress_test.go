@@ -0,0 +1,187 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package network_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlx"
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxconnection"
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxsql"
+	"github.com/golistic/pxmysql/xmysql/internal/network"
+)
+
+func frame(t *testing.T, msgType byte, msg proto.Message) []byte {
+	t.Helper()
+
+	b, err := proto.Marshal(msg)
+	xt.OK(t, err)
+
+	header := make([]byte, 5)
+	binary.LittleEndian.PutUint32(header, uint32(len(b))+1)
+	header[4] = msgType
+
+	return append(header, b...)
+}
+
+func readFrame(t *testing.T, r io.Reader) (byte, []byte) {
+	t.Helper()
+
+	var header [5]byte
+	_, err := io.ReadFull(r, header[:])
+	xt.OK(t, err)
+
+	payload := make([]byte, binary.LittleEndian.Uint32(header[:4])-1)
+	_, err = io.ReadFull(r, payload)
+	xt.OK(t, err)
+
+	return header[4], payload
+}
+
+func TestCompressedConn(t *testing.T) {
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := network.NewCompressedConn(nil, "lz4_message")
+		xt.KO(t, err)
+		xt.Eq(t, "unsupported compression algorithm lz4_message", err.Error())
+	})
+
+	t.Run("write", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer func() { _ = client.Close() }()
+
+		conn, err := network.NewCompressedConn(client, network.CompressionDeflateStream)
+		xt.OK(t, err)
+
+		large := &mysqlxsql.StmtExecute{Stmt: []byte("SELECT '" + strings.Repeat("a", 5000) + "'")}
+		small := &mysqlxsql.StmtExecute{Stmt: []byte("SELECT 1")}
+		messages := []*mysqlxsql.StmtExecute{large, large, small}
+
+		errs := make(chan error, 1)
+		go func() {
+			for _, msg := range messages {
+				if err := network.Write(context.Background(), conn, msg, 0); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+
+		var stream bytes.Buffer
+		var zr io.Reader
+		for _, msg := range messages {
+			exp := frame(t, byte(mysqlx.ClientMessages_SQL_STMT_EXECUTE), msg)
+
+			msgType, payload := readFrame(t, server)
+			if msg == small {
+				xt.Eq(t, byte(mysqlx.ClientMessages_SQL_STMT_EXECUTE), msgType)
+				xt.Eq(t, exp[5:], payload)
+				continue
+			}
+
+			xt.Eq(t, byte(mysqlx.ClientMessages_COMPRESSION), msgType)
+
+			compressed := &mysqlxconnection.Compression{}
+			xt.OK(t, proto.Unmarshal(payload, compressed))
+			xt.Eq(t, uint64(len(exp)), compressed.GetUncompressedSize())
+			xt.Eq(t, mysqlx.ClientMessages_SQL_STMT_EXECUTE, compressed.GetClientMessages())
+			xt.Assert(t, len(compressed.GetPayload()) < len(exp))
+
+			stream.Write(compressed.GetPayload())
+			if zr == nil {
+				zr, err = zlib.NewReader(&stream)
+				xt.OK(t, err)
+			}
+
+			got := make([]byte, compressed.GetUncompressedSize())
+			_, err = io.ReadFull(zr, got)
+			xt.OK(t, err)
+			xt.Eq(t, exp, got)
+		}
+
+		xt.OK(t, <-errs)
+	})
+
+	t.Run("read combined messages", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer func() { _ = client.Close() }()
+
+		conn, err := network.NewCompressedConn(client, network.CompressionDeflateStream)
+		xt.OK(t, err)
+
+		var stream bytes.Buffer
+		zw := zlib.NewWriter(&stream)
+
+		compressed := func(frames ...[]byte) []byte {
+			uncompressed := bytes.Join(frames, nil)
+			stream.Reset()
+			_, err := zw.Write(uncompressed)
+			xt.OK(t, err)
+			xt.OK(t, zw.Flush())
+
+			return frame(t, byte(mysqlx.ServerMessages_COMPRESSION), &mysqlxconnection.Compression{
+				UncompressedSize: proto.Uint64(uint64(len(uncompressed))),
+				Payload:          bytes.Clone(stream.Bytes()),
+			})
+		}
+
+		okFrame := func(msg string) []byte {
+			return frame(t, byte(mysqlx.ServerMessages_OK), &mysqlx.Ok{Msg: proto.String(msg)})
+		}
+
+		frames := [][]byte{
+			compressed(okFrame("first"), okFrame("second")),
+			okFrame("uncompressed"),
+			compressed(okFrame("third")),
+		}
+
+		go func() {
+			for _, b := range frames {
+				_, _ = server.Write(b)
+			}
+		}()
+
+		for _, exp := range []string{"first", "second", "uncompressed", "third"} {
+			msg, err := network.Read(context.Background(), conn)
+			xt.OK(t, err)
+			xt.Eq(t, mysqlx.ServerMessages_OK, msg.ServerMessageType())
+
+			ok := &mysqlx.Ok{}
+			xt.OK(t, msg.Unmarshall(ok))
+			xt.Eq(t, exp, ok.GetMsg())
+		}
+	})
+
+	t.Run("uncompressed size exceeds maximum", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer func() { _ = client.Close() }()
+
+		conn, err := network.NewCompressedConn(client, network.CompressionDeflateStream)
+		xt.OK(t, err)
+		conn.SetMaxUncompressedSize(1024)
+
+		b := frame(t, byte(mysqlx.ServerMessages_COMPRESSION), &mysqlxconnection.Compression{
+			UncompressedSize: proto.Uint64(1 << 40),
+			Payload:          []byte{0x78, 0x9c},
+		})
+
+		go func() {
+			_, _ = server.Write(b)
+		}()
+
+		_, err = network.Read(context.Background(), conn)
+		xt.KO(t, err)
+		xt.Assert(t, strings.Contains(err.Error(),
+			"uncompressed size of compressed message 1099511627776 exceeds maximum 1024"), err.Error())
+	})
+}
@@ -3,36 +3,152 @@
 package network
 
 import (
+	"bytes"
 	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 )
 
-var ServerCAPool *x509.CertPool
-var muServerCAPool sync.RWMutex
+// cachedCerts are the certificates parsed from a file, together with the
+// modification time and size of the file when it was read.
+type cachedCerts struct {
+	modTime time.Time
+	size    int64
+	certs   []*x509.Certificate
+}
+
+var (
+	certsCache   = map[string]cachedCerts{}
+	muCertsCache sync.Mutex
+)
+
+// CACertPoolFromFile returns a new certificate pool containing the CA
+// certificates read from the PEM encoded filename. When withSystemCAs is true,
+// the pool also contains the system's CAs.
+// Each call returns a new pool, so that trust is not shared between
+// connections. Parsed certificates are cached using the path, and are read
+// again when the modification time or size of the file changes, for example,
+// when the certificate was rotated.
+func CACertPoolFromFile(filename string, withSystemCAs bool) (*x509.CertPool, error) {
+	certs, err := certificatesFromFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if withSystemCAs {
+		if pool, err = x509.SystemCertPool(); err != nil {
+			return nil, fmt.Errorf("loading system CA certificates (%w)", err)
+		}
+	}
+
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
 
-func init() {
-	ServerCAPool = x509.NewCertPool()
+	return pool, nil
 }
 
-func addServerCACert(certs []byte) error {
+// certificatesFromFile returns the certificates within the PEM encoded filename.
+func certificatesFromFile(filename string) ([]*x509.Certificate, error) {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading server CA certificate (%w)", err)
+	}
 
-	muServerCAPool.Lock()
-	defer muServerCAPool.Unlock()
+	muCertsCache.Lock()
+	defer muCertsCache.Unlock()
 
-	if ok := ServerCAPool.AppendCertsFromPEM(certs); !ok {
-		return fmt.Errorf("appending CA certificate to pool")
+	if c, ok := certsCache[filename]; ok && c.modTime.Equal(stat.ModTime()) && c.size == stat.Size() {
+		return c.certs, nil
 	}
-	return nil
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading server CA certificate (%w)", err)
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing server CA certificate (%w)", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", filename)
+	}
+
+	certsCache[filename] = cachedCerts{
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+		certs:   certs,
+	}
+
+	return certs, nil
 }
 
-func AddServerCACertFromFile(filename string) error {
+// RevocationListFromFile returns the certificate revocation list (CRL) read
+// from the PEM or DER encoded filename.
+func RevocationListFromFile(filename string) (*x509.RevocationList, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading certificate revocation list (%w)", err)
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
 
-	certs, err := os.ReadFile(filename)
+	crl, err := x509.ParseRevocationList(data)
 	if err != nil {
-		return fmt.Errorf("reading server CA certificate (%w)", err)
+		return nil, fmt.Errorf("parsing certificate revocation list (%w)", err)
 	}
 
-	return addServerCACert(certs)
+	return crl, nil
+}
+
+// CheckRevoked returns an error when one of the certificates of the verified
+// chains is revoked by crl. The signature of crl is checked using the issuer
+// of the certificate within the chain, and crl is refused when its next update
+// is before now. The CRL is not used for certificates of other issuers.
+func CheckRevoked(chains [][]*x509.Certificate, crl *x509.RevocationList, now time.Time) error {
+	if !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+		return fmt.Errorf("certificate revocation list is stale (next update was %s)",
+			crl.NextUpdate.Format(time.RFC3339))
+	}
+
+	for _, chain := range chains {
+		for i := 0; i < len(chain)-1; i++ {
+			cert, issuer := chain[i], chain[i+1]
+			if !bytes.Equal(cert.RawIssuer, crl.RawIssuer) {
+				continue
+			}
+
+			if err := crl.CheckSignatureFrom(issuer); err != nil {
+				return fmt.Errorf("verifying certificate revocation list (%w)", err)
+			}
+
+			for _, entry := range crl.RevokedCertificateEntries {
+				if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+					return fmt.Errorf("server certificate %s was revoked", cert.Subject)
+				}
+			}
+		}
+	}
+
+	return nil
 }
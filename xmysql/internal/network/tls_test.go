@@ -0,0 +1,252 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package network_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/xmysql/internal/network"
+)
+
+func newCA(t *testing.T, name string) (*x509.Certificate, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	xt.OK(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	xt.OK(t, err)
+	cert, err := x509.ParseCertificate(der)
+	xt.OK(t, err)
+
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func trusts(pool *x509.CertPool, cert *x509.Certificate) bool {
+	_, err := cert.Verify(x509.VerifyOptions{Roots: pool})
+	return err == nil
+}
+
+func TestCACertPoolFromFile(t *testing.T) {
+	dir := t.TempDir()
+
+	caA, pemA := newCA(t, "CA A")
+	caB, pemB := newCA(t, "CA B")
+
+	fileA := filepath.Join(dir, "a.pem")
+	fileB := filepath.Join(dir, "b.pem")
+	xt.OK(t, os.WriteFile(fileA, pemA, 0600))
+	xt.OK(t, os.WriteFile(fileB, pemB, 0600))
+
+	t.Run("pools do not share trust", func(t *testing.T) {
+		poolA, err := network.CACertPoolFromFile(fileA, false)
+		xt.OK(t, err)
+		poolB, err := network.CACertPoolFromFile(fileB, false)
+		xt.OK(t, err)
+
+		xt.Assert(t, trusts(poolA, caA))
+		xt.Assert(t, !trusts(poolA, caB))
+		xt.Assert(t, trusts(poolB, caB))
+		xt.Assert(t, !trusts(poolB, caA))
+	})
+
+	t.Run("certificate is read again when file changes", func(t *testing.T) {
+		file := filepath.Join(dir, "rotated.pem")
+		xt.OK(t, os.WriteFile(file, pemA, 0600))
+
+		pool, err := network.CACertPoolFromFile(file, false)
+		xt.OK(t, err)
+		xt.Assert(t, trusts(pool, caA))
+
+		xt.OK(t, os.WriteFile(file, pemB, 0600))
+		later := time.Now().Add(time.Minute)
+		xt.OK(t, os.Chtimes(file, later, later))
+
+		pool, err = network.CACertPoolFromFile(file, false)
+		xt.OK(t, err)
+		xt.Assert(t, trusts(pool, caB))
+		xt.Assert(t, !trusts(pool, caA))
+	})
+
+	t.Run("with system CAs", func(t *testing.T) {
+		if _, err := x509.SystemCertPool(); err != nil {
+			t.Skip("system CAs not available")
+		}
+
+		pool, err := network.CACertPoolFromFile(fileA, true)
+		xt.OK(t, err)
+		xt.Assert(t, trusts(pool, caA))
+	})
+
+	t.Run("file without certificates", func(t *testing.T) {
+		file := filepath.Join(dir, "empty.pem")
+		xt.OK(t, os.WriteFile(file, []byte("not a certificate"), 0600))
+
+		_, err := network.CACertPoolFromFile(file, false)
+		xt.KO(t, err)
+		xt.Eq(t, "no certificates found in "+file, err.Error())
+	})
+
+	t.Run("file not available", func(t *testing.T) {
+		_, err := network.CACertPoolFromFile(filepath.Join(dir, "missing.pem"), false)
+		xt.KO(t, err)
+	})
+}
+
+func newIssuedCert(t *testing.T, serial int64, name string,
+	parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	xt.OK(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	xt.OK(t, err)
+	cert, err := x509.ParseCertificate(der)
+	xt.OK(t, err)
+
+	return cert, key
+}
+
+func newCRL(t *testing.T, issuer *x509.Certificate, key *ecdsa.PrivateKey, nextUpdate time.Time,
+	revoked ...*x509.Certificate) []byte {
+	t.Helper()
+
+	var entries []x509.RevocationListEntry
+	for _, cert := range revoked {
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: cert.SerialNumber, RevocationTime: time.Now()})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		RevokedCertificateEntries: entries,
+		ThisUpdate:                time.Now().Add(-time.Hour),
+		NextUpdate:                nextUpdate,
+	}, issuer, key)
+	xt.OK(t, err)
+
+	return der
+}
+
+func TestRevocationListFromFile(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := newIssuedCert(t, 1, "pxmysql CA", nil, nil)
+	der := newCRL(t, ca, caKey, time.Now().Add(time.Hour))
+
+	t.Run("PEM and DER", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"crl.pem": pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}),
+			"crl.der": der,
+		} {
+			t.Run(name, func(t *testing.T) {
+				file := filepath.Join(dir, name)
+				xt.OK(t, os.WriteFile(file, data, 0600))
+
+				crl, err := network.RevocationListFromFile(file)
+				xt.OK(t, err)
+				xt.Eq(t, ca.RawSubject, crl.RawIssuer)
+			})
+		}
+	})
+
+	t.Run("not a revocation list", func(t *testing.T) {
+		file := filepath.Join(dir, "garbage.pem")
+		xt.OK(t, os.WriteFile(file, []byte("not a CRL"), 0600))
+
+		_, err := network.RevocationListFromFile(file)
+		xt.KO(t, err)
+	})
+
+	t.Run("file not available", func(t *testing.T) {
+		_, err := network.RevocationListFromFile(filepath.Join(dir, "missing.pem"))
+		xt.KO(t, err)
+	})
+}
+
+func TestCheckRevoked(t *testing.T) {
+	ca, caKey := newIssuedCert(t, 1, "pxmysql CA", nil, nil)
+	server, _ := newIssuedCert(t, 2, "server", ca, caKey)
+	other, _ := newIssuedCert(t, 3, "other", ca, caKey)
+	chains := [][]*x509.Certificate{{server, ca}}
+
+	parse := func(t *testing.T, der []byte) *x509.RevocationList {
+		t.Helper()
+		crl, err := x509.ParseRevocationList(der)
+		xt.OK(t, err)
+		return crl
+	}
+
+	t.Run("revoked", func(t *testing.T) {
+		crl := parse(t, newCRL(t, ca, caKey, time.Now().Add(time.Hour), server))
+
+		err := network.CheckRevoked(chains, crl, time.Now())
+		xt.KO(t, err)
+		xt.Eq(t, "server certificate CN=server was revoked", err.Error())
+
+		xt.OK(t, network.CheckRevoked([][]*x509.Certificate{{other, ca}}, crl, time.Now()))
+	})
+
+	t.Run("revocation list of other issuer is not used", func(t *testing.T) {
+		otherCA, otherKey := newIssuedCert(t, 1, "other CA", nil, nil)
+		crl := parse(t, newCRL(t, otherCA, otherKey, time.Now().Add(time.Hour), server))
+
+		xt.OK(t, network.CheckRevoked(chains, crl, time.Now()))
+	})
+
+	t.Run("revocation list not signed by issuer", func(t *testing.T) {
+		impostor, impostorKey := newIssuedCert(t, 1, "pxmysql CA", nil, nil)
+		crl := parse(t, newCRL(t, impostor, impostorKey, time.Now().Add(time.Hour), server))
+
+		err := network.CheckRevoked(chains, crl, time.Now())
+		xt.KO(t, err)
+		xt.Assert(t, strings.HasPrefix(err.Error(), "verifying certificate revocation list ("), err.Error())
+	})
+
+	t.Run("stale revocation list", func(t *testing.T) {
+		nextUpdate := time.Now().Add(time.Hour)
+		crl := parse(t, newCRL(t, ca, caKey, nextUpdate))
+
+		err := network.CheckRevoked(chains, crl, nextUpdate.Add(time.Minute))
+		xt.KO(t, err)
+		xt.Eq(t, "certificate revocation list is stale (next update was "+
+			crl.NextUpdate.Format(time.RFC3339)+")", err.Error())
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package optionfile
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// loginKeyLen is the length of the key stored in the login path file.
+const loginKeyLen = 20
+
+// loginHeaderLen is the length of the unused bytes followed by the key
+// at the start of the login path file.
+const loginHeaderLen = 4 + loginKeyLen
+
+// ReadLogin reads the login path file path created by mysql_config_editor,
+// usually ~/.mylogin.cnf, and returns its groups.
+func ReadLogin(path string) (Groups, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading login path file (%w)", err)
+	}
+
+	plain, err := DecryptLogin(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed decrypting login path file %s (%w)", path, err)
+	}
+
+	groups := Groups{}
+	if err := parse(groups, plain, path, 0); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// DecryptLogin returns the content of a login path file. The file starts
+// with 4 unused bytes and the key, followed by each line encrypted using
+// AES-128-ECB, prefixed with its length (4 bytes, little endian).
+func DecryptLogin(data []byte) ([]byte, error) {
+	if len(data) < loginHeaderLen {
+		return nil, fmt.Errorf("file too short")
+	}
+
+	cipher, err := aes.NewCipher(loginAESKey(data[4:loginHeaderLen]))
+	if err != nil {
+		return nil, err
+	}
+
+	var plain bytes.Buffer
+	data = data[loginHeaderLen:]
+
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("invalid line length")
+		}
+		size := int(binary.LittleEndian.Uint32(data[:4]))
+		data = data[4:]
+
+		if size == 0 || size%aes.BlockSize != 0 || size > len(data) {
+			return nil, fmt.Errorf("invalid line length")
+		}
+
+		line := make([]byte, size)
+		for i := 0; i < size; i += aes.BlockSize {
+			cipher.Decrypt(line[i:i+aes.BlockSize], data[i:i+aes.BlockSize])
+		}
+		data = data[size:]
+
+		pad := int(line[size-1])
+		if pad == 0 || pad > aes.BlockSize {
+			return nil, fmt.Errorf("invalid padding")
+		}
+		plain.Write(line[:size-pad])
+	}
+
+	return plain.Bytes(), nil
+}
+
+// EncryptLogin returns plain as content of a login path file, which can
+// be read using DecryptLogin.
+func EncryptLogin(plain []byte) ([]byte, error) {
+	var key [loginKeyLen]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return nil, err
+	}
+
+	cipher, err := aes.NewCipher(loginAESKey(key[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 4, loginHeaderLen)
+	data = append(data, key[:]...)
+
+	for _, line := range bytes.SplitAfter(plain, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		pad := aes.BlockSize - len(line)%aes.BlockSize
+		line = append(bytes.Clone(line), bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+		encrypted := make([]byte, len(line))
+		for i := 0; i < len(line); i += aes.BlockSize {
+			cipher.Encrypt(encrypted[i:i+aes.BlockSize], line[i:i+aes.BlockSize])
+		}
+
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(encrypted)))
+		data = append(data, encrypted...)
+	}
+
+	return data, nil
+}
+
+// loginAESKey folds the key stored in the login path file into a 16 byte
+// AES key, like MySQL does.
+func loginAESKey(key []byte) []byte {
+	aesKey := make([]byte, 16)
+	for i, b := range key {
+		aesKey[i%len(aesKey)] ^= b
+	}
+
+	return aesKey
+}
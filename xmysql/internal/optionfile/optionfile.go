@@ -0,0 +1,223 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+// Package optionfile reads MySQL option files, such as my.cnf, and the
+// obfuscated login path file (.mylogin.cnf) created by mysql_config_editor.
+package optionfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// maxIncludeDepth is the maximum nesting of !include and !includedir
+// directives, which guards against files including each other.
+const maxIncludeDepth = 10
+
+// Groups maps the names of the groups found in option files to their
+// options. Option names use dashes instead of underscores, and options
+// without value have an empty string as value.
+type Groups map[string]map[string]string
+
+// Options returns the options of the groups names. When an option is
+// available in several groups, the value of the last group is used.
+func (g Groups) Options(names ...string) map[string]string {
+	options := map[string]string{}
+	for _, name := range names {
+		for k, v := range g[name] {
+			options[k] = v
+		}
+	}
+
+	return options
+}
+
+func (g Groups) set(group, name, value string) {
+	if g[group] == nil {
+		g[group] = map[string]string{}
+	}
+	g[group][name] = value
+}
+
+// Read reads the option file path, including the files given using the
+// !include and !includedir directives. Relative paths of these directives
+// are relative to the directory of the file containing them.
+func Read(path string) (Groups, error) {
+	groups := Groups{}
+	if err := readFile(groups, path, 0); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func readFile(groups Groups, path string, depth int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed reading option file (%w)", err)
+	}
+
+	return parse(groups, data, path, depth)
+}
+
+// parse parses the content data of the option file path and adds the
+// options to groups.
+func parse(groups Groups, data []byte, path string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("option file %s: includes nested too deep (max %d)", path, maxIncludeDepth)
+	}
+
+	var group string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNr := 0
+
+	for scanner.Scan() {
+		lineNr++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case strings.HasPrefix(line, "!include"):
+			if err := include(groups, line, path, depth); err != nil {
+				return fmt.Errorf("option file %s line %d: %w", path, lineNr, err)
+			}
+			continue
+		case line[0] == '[':
+			end := strings.IndexByte(line, ']')
+			if end == -1 {
+				return fmt.Errorf("option file %s line %d: invalid group", path, lineNr)
+			}
+			group = strings.ToLower(strings.TrimSpace(line[1:end]))
+			if group == "" {
+				return fmt.Errorf("option file %s line %d: invalid group", path, lineNr)
+			}
+			continue
+		}
+
+		if group == "" {
+			return fmt.Errorf("option file %s line %d: option outside group", path, lineNr)
+		}
+
+		name, value, hasValue := strings.Cut(line, "=")
+		if !hasValue {
+			name, _, _ = strings.Cut(name, "#")
+		}
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+		if name == "" {
+			return fmt.Errorf("option file %s line %d: option name missing", path, lineNr)
+		}
+
+		if hasValue {
+			var err error
+			if value, err = parseValue(value); err != nil {
+				return fmt.Errorf("option file %s line %d: %w", path, lineNr, err)
+			}
+		}
+
+		groups.set(group, name, value)
+	}
+
+	return scanner.Err()
+}
+
+// include handles the !include and !includedir directives found in line.
+func include(groups Groups, line, path string, depth int) error {
+	fields := strings.Fields(line)
+	directive := fields[0]
+	if len(fields) < 2 {
+		return fmt.Errorf("%s needs a path", directive)
+	}
+	// paths can contain spaces
+	target := strings.TrimSpace(strings.TrimPrefix(line, directive))
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+
+	switch directive {
+	case "!include":
+		return readFile(groups, target, depth+1)
+	case "!includedir":
+		entries, err := os.ReadDir(target)
+		if err != nil {
+			return fmt.Errorf("failed reading option directory (%w)", err)
+		}
+
+		var names []string
+		for _, e := range entries {
+			ext := filepath.Ext(e.Name())
+			if e.Type().IsRegular() && (ext == ".cnf" || (runtime.GOOS == "windows" && ext == ".ini")) {
+				names = append(names, e.Name())
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if err := readFile(groups, filepath.Join(target, name), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown directive %s", directive)
+	}
+}
+
+// parseValue returns the value of an option as found after the equal sign.
+// Quoted values keep their whitespace, and escape sequences such as \n
+// and \s (space) are replaced. Unquoted values end at a comment (#).
+func parseValue(s string) (string, error) {
+	s = strings.TrimSpace(s)
+
+	var quote byte
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		quote = s[0]
+		s = s[1:]
+	}
+
+	var value strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0 && c == quote:
+			if rest := strings.TrimSpace(s[i+1:]); rest != "" && rest[0] != '#' {
+				return "", fmt.Errorf("unexpected characters after quoted value")
+			}
+			return value.String(), nil
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(value.String()), nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'b':
+				value.WriteByte('\b')
+			case 't':
+				value.WriteByte('\t')
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 's':
+				value.WriteByte(' ')
+			case '\\', '"', '\'':
+				value.WriteByte(s[i])
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(s[i])
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	if quote != 0 {
+		return "", fmt.Errorf("missing closing quote")
+	}
+
+	return strings.TrimSpace(value.String()), nil
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package optionfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/xmysql/internal/optionfile"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	xt.OK(t, os.MkdirAll(filepath.Dir(path), 0700))
+	xt.OK(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestRead(t *testing.T) {
+	t.Run("groups and values", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "my.cnf")
+		writeFile(t, path, `
+# comment
+; also comment
+[client]
+user = scott
+password = "tiger # not a comment "
+host=db.example.com  # comment
+ssl_mode = REQUIRED
+socket='/tmp/my sql.sock'
+escaped = a\sb\\c
+skip-ssl
+
+[MySQLX]
+port = 33060
+user = other
+`)
+
+		groups, err := optionfile.Read(path)
+		xt.OK(t, err)
+
+		xt.Eq(t, map[string]string{
+			"user":     "scott",
+			"password": "tiger # not a comment ",
+			"host":     "db.example.com",
+			"ssl-mode": "REQUIRED",
+			"socket":   "/tmp/my sql.sock",
+			"escaped":  `a b\c`,
+			"skip-ssl": "",
+		}, groups["client"])
+
+		options := groups.Options("client", "mysqlx")
+		xt.Eq(t, "other", options["user"])
+		xt.Eq(t, "33060", options["port"])
+		xt.Eq(t, "db.example.com", options["host"])
+	})
+
+	t.Run("include and includedir", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "my.cnf")
+
+		writeFile(t, path, `
+[client]
+user = scott
+!include extra/credentials.cnf
+!includedir conf.d
+host = localhost
+`)
+		writeFile(t, filepath.Join(dir, "extra", "credentials.cnf"), "[client]\npassword = tiger\n")
+		writeFile(t, filepath.Join(dir, "conf.d", "b.cnf"), "[client]\nport = 33070\n")
+		writeFile(t, filepath.Join(dir, "conf.d", "a.cnf"), "[client]\nport = 33060\n[mysqlx]\nhost = a\n")
+		writeFile(t, filepath.Join(dir, "conf.d", "ignored.txt"), "[client]\nuser = ignored\n")
+
+		groups, err := optionfile.Read(path)
+		xt.OK(t, err)
+
+		xt.Eq(t, map[string]string{
+			"user":     "scott",
+			"password": "tiger",
+			"port":     "33070",
+			"host":     "localhost",
+		}, groups["client"])
+		xt.Eq(t, "a", groups["mysqlx"]["host"])
+	})
+
+	t.Run("include separated by tab", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "my.cnf")
+
+		writeFile(t, path, "[client]\n!include\textra.cnf\n!includedir \t conf.d\n")
+		writeFile(t, filepath.Join(dir, "extra.cnf"), "[client]\nuser = scott\n")
+		writeFile(t, filepath.Join(dir, "conf.d", "a.cnf"), "[client]\npassword = tiger\n")
+
+		groups, err := optionfile.Read(path)
+		xt.OK(t, err)
+		xt.Eq(t, map[string]string{"user": "scott", "password": "tiger"}, groups["client"])
+	})
+
+	t.Run("including itself", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "my.cnf")
+		writeFile(t, path, "[client]\n!include my.cnf\n")
+
+		_, err := optionfile.Read(path)
+		xt.KO(t, err)
+		xt.Assert(t, strings.Contains(err.Error(), "includes nested too deep (max 10)"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var cases = map[string]string{
+			"user = scott\n":              "line 1: option outside group",
+			"[client\n":                   "line 1: invalid group",
+			"[client]\n= value\n":         "line 2: option name missing",
+			"[client]\nuser = 'scott\n":   "line 2: missing closing quote",
+			"[client]\nuser = 'a' b\n":    "line 2: unexpected characters after quoted value",
+			"[client]\n!includefile x\n":  "line 2: unknown directive !includefile",
+			"[client]\n!include\n":        "line 2: !include needs a path",
+			"[client]\n!include nope.cnf": "line 2: failed reading option file",
+		}
+
+		for content, exp := range cases {
+			t.Run(exp, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), "my.cnf")
+				writeFile(t, path, content)
+
+				_, err := optionfile.Read(path)
+				xt.KO(t, err)
+				xt.Assert(t, strings.Contains(err.Error(), exp), err.Error())
+			})
+		}
+	})
+
+	t.Run("file not available", func(t *testing.T) {
+		_, err := optionfile.Read(filepath.Join(t.TempDir(), "missing.cnf"))
+		xt.KO(t, err)
+	})
+}
+
+func TestReadLogin(t *testing.T) {
+	content := "[client]\nuser = \"root\"\npassword = \"s3cret with spaces\"\n" +
+		"[remote]\nuser = \"scott\"\nhost = \"db.example.com\"\nport = 33060\n"
+
+	t.Run("decrypt", func(t *testing.T) {
+		data, err := optionfile.EncryptLogin([]byte(content))
+		xt.OK(t, err)
+		xt.Assert(t, !strings.Contains(string(data), "s3cret"))
+
+		plain, err := optionfile.DecryptLogin(data)
+		xt.OK(t, err)
+		xt.Eq(t, content, string(plain))
+	})
+
+	t.Run("read", func(t *testing.T) {
+		data, err := optionfile.EncryptLogin([]byte(content))
+		xt.OK(t, err)
+
+		path := filepath.Join(t.TempDir(), ".mylogin.cnf")
+		xt.OK(t, os.WriteFile(path, data, 0600))
+
+		groups, err := optionfile.ReadLogin(path)
+		xt.OK(t, err)
+		xt.Eq(t, "s3cret with spaces", groups["client"]["password"])
+
+		options := groups.Options("client", "remote")
+		xt.Eq(t, "scott", options["user"])
+		xt.Eq(t, "s3cret with spaces", options["password"])
+		xt.Eq(t, "db.example.com", options["host"])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		data, err := optionfile.EncryptLogin([]byte(content))
+		xt.OK(t, err)
+
+		var cases = map[string][]byte{
+			"file too short":      data[:10],
+			"invalid line length": data[:len(data)-3],
+		}
+
+		for exp, d := range cases {
+			t.Run(exp, func(t *testing.T) {
+				_, err := optionfile.DecryptLogin(d)
+				xt.KO(t, err)
+				xt.Eq(t, exp, err.Error())
+			})
+		}
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package statements
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ExpandSlices replaces each placeholder within stmt which is bound to a slice
+// with as many placeholders as the slice has elements, separated by commas. The
+// returned arguments have the slices replaced by their elements. This is useful
+// for, for example, `IN (?)`.
+// Slices of bytes and values implementing driver.Valuer are not expanded.
+// An error is returned when a slice is empty, since `IN ()` is not valid SQL.
+func ExpandSlices(stmt string, mode Mode, args []any) (string, []any, error) {
+	placeholders := PlaceholderIndexes(stmt, mode)
+	if len(placeholders) != len(args) {
+		return "", nil, fmt.Errorf("need %d argument(s); got %d", len(placeholders), len(args))
+	}
+
+	var expanded []any
+	var buf strings.Builder
+
+	var index int
+	for i, ph := range placeholders {
+		rv, ok := expandable(args[i])
+		if !ok {
+			expanded = append(expanded, args[i])
+			continue
+		}
+
+		if rv.Len() == 0 {
+			return "", nil, fmt.Errorf("cannot expand empty slice for placeholder %d", i+1)
+		}
+
+		buf.WriteString(stmt[index:ph])
+		for j := 0; j < rv.Len(); j++ {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteByte(Placeholder)
+			expanded = append(expanded, rv.Index(j).Interface())
+		}
+		index = ph + 1
+	}
+
+	buf.WriteString(stmt[index:])
+
+	return buf.String(), expanded, nil
+}
+
+// expandable returns the reflected value of arg, and whether it is a slice
+// which can be expanded.
+func expandable(arg any) (reflect.Value, bool) {
+	if _, ok := arg.(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+
+	rv := reflect.ValueOf(arg)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return reflect.Value{}, false
+	}
+
+	return rv, true
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package statements_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/null"
+	"github.com/golistic/pxmysql/xmysql/internal/statements"
+)
+
+func TestExpandSlices(t *testing.T) {
+	var cases = []struct {
+		stmt     string
+		args     []any
+		expStmt  string
+		expArgs  []any
+		expError string
+	}{
+		{
+			stmt:    `SELECT * FROM t WHERE id IN (?)`,
+			args:    []any{[]int{1, 2, 3}},
+			expStmt: `SELECT * FROM t WHERE id IN (?, ?, ?)`,
+			expArgs: []any{1, 2, 3},
+		},
+		{
+			stmt:    `SELECT * FROM t WHERE c = ? AND name IN (?) AND '?' = ?`,
+			args:    []any{1, []string{"a", "b"}, 2},
+			expStmt: `SELECT * FROM t WHERE c = ? AND name IN (?, ?) AND '?' = ?`,
+			expArgs: []any{1, "a", "b", 2},
+		},
+		{
+			stmt:    `SELECT ?, ?, ?`,
+			args:    []any{[]byte("raw"), json.RawMessage(`{}`), null.Strings{Strings: []string{"a"}, Valid: true}},
+			expStmt: `SELECT ?, ?, ?`,
+			expArgs: []any{[]byte("raw"), json.RawMessage(`{}`), null.Strings{Strings: []string{"a"}, Valid: true}},
+		},
+		{
+			stmt:     `SELECT * FROM t WHERE id IN (?)`,
+			args:     []any{[]int{}},
+			expError: "cannot expand empty slice for placeholder 1",
+		},
+		{
+			stmt:     `SELECT ?, ?`,
+			args:     []any{[]int{1}},
+			expError: "need 2 argument(s); got 1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.stmt, func(t *testing.T) {
+			stmt, args, err := statements.ExpandSlices(c.stmt, 0, c.args)
+			if c.expError != "" {
+				xt.KO(t, err)
+				xt.Eq(t, c.expError, err.Error())
+				return
+			}
+			xt.OK(t, err)
+			xt.Eq(t, c.expStmt, stmt)
+			xt.Eq(t, c.expArgs, args)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package statements_test
+
+import (
+	"testing"
+
+	"github.com/golistic/pxmysql/xmysql/internal/statements"
+)
+
+var fuzzQueries = []string{
+	`SELECT ?`,
+	`SELECT * FROM t WHERE id = ? AND name = 'it''s ?' AND note = "say \"?\""`,
+	"SELECT `weird ? column`, c2 FROM `db`.`t?` WHERE c1 IN (?, ?, ?)",
+	"INSERT INTO t (a, b) VALUES (?, ?) -- comment with ?\n",
+	"SELECT ? # MySQL comment ?\nFROM dual",
+	`SELECT /* block ? comment */ ? FROM dual /* not closed ?`,
+	`SELECT /*+ MAX_EXECUTION_TIME(1000) */ ? FROM t`,
+	`CREATE TABLE t (c INT) /*!50100 PARTITION BY HASH (c) */`,
+	`SELECT 'C:\', ?, '\?'`,
+	`SELECT "a\", ?, "\?"`,
+	`UPDATE t SET c = :value WHERE id = :id OR parent = :id`,
+	`SET @v := ?, @@SESSION.sql_mode = 'ANSI_QUOTES'`,
+	`SELECT '🐰', ?, 'ñ?', ?`,
+	`SELECT 1--?` + "\n" + `, ?`,
+}
+
+func FuzzPlaceholderIndexes(f *testing.F) {
+	for _, q := range fuzzQueries {
+		for mode := uint8(0); mode < 4; mode++ {
+			f.Add(q, mode)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, query string, m uint8) {
+		mode := statements.Mode(m & 3)
+		indexes := statements.PlaceholderIndexes(query, mode)
+
+		prev := -1
+		for _, i := range indexes {
+			if i <= prev || i >= len(query) || query[i] != statements.Placeholder {
+				t.Fatalf("invalid placeholder index %d in %q (%v)", i, query, indexes)
+			}
+			prev = i
+		}
+
+		args := make([]any, len(indexes))
+		for i := range args {
+			args[i] = 1
+		}
+
+		substituted, err := statements.SubstitutePlaceholders(query, mode, args...)
+		if err != nil {
+			t.Fatalf("substituting %q: %s", query, err)
+		}
+
+		if n := len(statements.PlaceholderIndexes(substituted, mode)); n != 0 {
+			t.Fatalf("expected no placeholders after substitution; found %d in %q", n, substituted)
+		}
+	})
+}
+
+func FuzzRewriteNamed(f *testing.F) {
+	for _, q := range fuzzQueries {
+		for mode := uint8(0); mode < 4; mode++ {
+			f.Add(q, mode)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, query string, m uint8) {
+		mode := statements.Mode(m & 3)
+
+		rewritten, names, err := statements.RewriteNamed(query, mode, func(string) bool { return true })
+		if err != nil || len(names) == 0 {
+			return
+		}
+
+		if n := len(statements.PlaceholderIndexes(rewritten, mode)); n != len(names) {
+			t.Fatalf("expected %d placeholders in %q; found %d", len(names), rewritten, n)
+		}
+	})
+}
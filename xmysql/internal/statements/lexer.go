@@ -0,0 +1,143 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package statements
+
+import (
+	"strings"
+)
+
+// Mode holds the MySQL SQL modes which influence how statements are tokenized.
+type Mode uint8
+
+const (
+	// NoBackslashEscapes disables the backslash as escape character within
+	// string literals (SQL mode NO_BACKSLASH_ESCAPES).
+	NoBackslashEscapes Mode = 1 << iota
+	// ANSIQuotes makes double quotes quote identifiers instead of string
+	// literals (SQL mode ANSI_QUOTES).
+	ANSIQuotes
+)
+
+// ParseSQLMode returns the Mode using the value of the MySQL sql_mode
+// system variable, for example "ANSI_QUOTES,NO_BACKSLASH_ESCAPES".
+func ParseSQLMode(sqlMode string) Mode {
+	var mode Mode
+
+	for _, m := range strings.Split(sqlMode, ",") {
+		switch strings.ToUpper(strings.TrimSpace(m)) {
+		case "NO_BACKSLASH_ESCAPES":
+			mode |= NoBackslashEscapes
+		case "ANSI_QUOTES", "ANSI":
+			mode |= ANSIQuotes
+		}
+	}
+
+	return mode
+}
+
+// lexer walks over a SQL statement skipping string literals, quoted
+// identifiers, and comments. Offsets are in bytes.
+type lexer struct {
+	stmt string
+	mode Mode
+	pos  int
+	// versionComment is true within /*! ... */ which content MySQL executes
+	versionComment bool
+}
+
+func newLexer(stmt string, mode Mode) *lexer {
+	return &lexer{stmt: stmt, mode: mode}
+}
+
+// next returns the offset of the next byte which is part of the SQL code
+// itself, thus not within a string literal, quoted identifier or comment.
+// It returns -1 when the end of the statement is reached.
+func (l *lexer) next() int {
+	for l.pos < len(l.stmt) {
+		i := l.pos
+		c := l.stmt[i]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			l.pos = l.skipQuoted(i)
+		case c == '#':
+			l.pos = l.skipLine(i)
+		case c == '-' && l.isDashComment(i):
+			l.pos = l.skipLine(i)
+		case c == '/' && l.peek(i+1) == '*' && l.peek(i+2) == '!':
+			l.versionComment = true
+			l.pos = i + 3
+			for n := 0; n < 6 && isDigit(l.peek(l.pos)); n++ {
+				l.pos++
+			}
+		case c == '/' && l.peek(i+1) == '*':
+			if end := strings.Index(l.stmt[i+2:], "*/"); end < 0 {
+				l.pos = len(l.stmt)
+			} else {
+				l.pos = i + 2 + end + 2
+			}
+		case c == '*' && l.versionComment && l.peek(i+1) == '/':
+			l.versionComment = false
+			l.pos = i + 2
+		default:
+			l.pos = i + 1
+			return i
+		}
+	}
+
+	return -1
+}
+
+// peek returns the byte at offset i, or 0 when i is out of range.
+func (l *lexer) peek(i int) byte {
+	if i < len(l.stmt) {
+		return l.stmt[i]
+	}
+	return 0
+}
+
+// skipQuoted returns the offset just after the string literal or quoted
+// identifier starting at offset i.
+func (l *lexer) skipQuoted(i int) int {
+	quote := l.stmt[i]
+
+	escapes := l.mode&NoBackslashEscapes == 0 && quote != '`' &&
+		!(quote == '"' && l.mode&ANSIQuotes != 0)
+
+	for j := i + 1; j < len(l.stmt); j++ {
+		switch c := l.stmt[j]; {
+		case escapes && c == '\\':
+			j++
+		case c == quote && l.peek(j+1) == quote: // doubled quote
+			j++
+		case c == quote:
+			return j + 1
+		}
+	}
+
+	return len(l.stmt)
+}
+
+// skipLine returns the offset of the first byte of the next line.
+func (l *lexer) skipLine(i int) int {
+	if end := strings.IndexByte(l.stmt[i:], '\n'); end >= 0 {
+		return i + end + 1
+	}
+	return len(l.stmt)
+}
+
+// isDashComment returns whether a "-- " comment starts at offset i. The
+// second dash must be followed by whitespace, a control character, or
+// the end of the statement.
+func (l *lexer) isDashComment(i int) bool {
+	if l.peek(i+1) != '-' {
+		return false
+	}
+
+	if i+2 >= len(l.stmt) {
+		return true
+	}
+
+	c := l.stmt[i+2]
+	return c <= ' ' || c == 0x7f
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package statements
+
+import (
+	"fmt"
+)
+
+// RewriteNamed replaces the named placeholders within stmt with the positional
+// Placeholder. It returns the rewritten statement and the names of the
+// placeholders in the order they appear; a name appears as many times as it is
+// used within stmt.
+// Named placeholders have the form :name. The form @name is also a placeholder
+// when isArg reports true for name, otherwise it is a MySQL user variable.
+// Names start with a letter or underscore followed by letters, digits, or underscores.
+// Like with PlaceholderIndexes, string literals, quoted identifiers, and comments
+// are skipped.
+// An error is returned when stmt mixes named and positional placeholders.
+func RewriteNamed(stmt string, mode Mode, isArg func(name string) bool) (string, []string, error) {
+	var names []string
+	var buf []byte
+	var positional bool
+
+	var index int
+	lex := newLexer(stmt, mode)
+	for i := lex.next(); i >= 0; i = lex.next() {
+		c := stmt[i]
+
+		switch {
+		case c == Placeholder:
+			positional = true
+			continue
+		case c != ':' && c != '@':
+			continue
+		}
+
+		// previous character must not be part of a name (e.g. @@var, a:b)
+		if i > 0 && (isNameChar(stmt[i-1]) || stmt[i-1] == '@' || stmt[i-1] == ':') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(stmt) && isNameChar(stmt[end]) {
+			end++
+		}
+		if end == i+1 || isDigit(stmt[i+1]) {
+			continue
+		}
+
+		name := stmt[i+1 : end]
+		if c == '@' && (isArg == nil || !isArg(name)) {
+			continue
+		}
+
+		names = append(names, name)
+		buf = append(buf, stmt[index:i]...)
+		buf = append(buf, Placeholder)
+		index = end
+		lex.pos = end
+	}
+
+	if len(names) == 0 {
+		return stmt, nil, nil
+	}
+
+	if positional {
+		return "", nil, fmt.Errorf("mixing named and positional placeholders not supported")
+	}
+
+	return string(append(buf, stmt[index:]...)), names, nil
+}
+
+// BindNamed returns the values of args in the order of names, as returned
+// by RewriteNamed. An error is returned when an argument is missing for a name,
+// or when an argument is not used.
+func BindNamed(names []string, args map[string]any) ([]any, error) {
+	used := map[string]bool{}
+	values := make([]any, len(names))
+
+	for i, name := range names {
+		v, ok := args[name]
+		if !ok {
+			return nil, fmt.Errorf("missing argument for named placeholder '%s'", name)
+		}
+		values[i] = v
+		used[name] = true
+	}
+
+	for name := range args {
+		if !used[name] {
+			return nil, fmt.Errorf("named argument '%s' not used", name)
+		}
+	}
+
+	return values, nil
+}
+
+// UniqueNames returns names without duplicates, keeping the order in which they
+// first appear.
+func UniqueNames(names []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+
+	return unique
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
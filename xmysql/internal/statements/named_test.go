@@ -0,0 +1,98 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package statements_test
+
+import (
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/xmysql/internal/statements"
+)
+
+func TestRewriteNamed(t *testing.T) {
+	isArg := func(name string) bool {
+		return name == "id" || name == "name"
+	}
+
+	var cases = []struct {
+		stmt     string
+		expStmt  string
+		expNames []string
+	}{
+		{
+			stmt:     `SELECT :id`,
+			expStmt:  `SELECT ?`,
+			expNames: []string{"id"},
+		},
+		{
+			stmt:     `SELECT * FROM t WHERE id = :id OR parent = :id AND name = @name`,
+			expStmt:  `SELECT * FROM t WHERE id = ? OR parent = ? AND name = ?`,
+			expNames: []string{"id", "id", "name"},
+		},
+		{
+			stmt:     `SELECT ':id', ":id", @@version, @other, @'name', :id`,
+			expStmt:  `SELECT ':id', ":id", @@version, @other, @'name', ?`,
+			expNames: []string{"id"},
+		},
+		{
+			stmt:    `SET @v := 1, @@session.sql_mode = ''`,
+			expStmt: `SET @v := 1, @@session.sql_mode = ''`,
+		},
+		{
+			stmt:    `SELECT '12:30', a:b, :1`,
+			expStmt: `SELECT '12:30', a:b, :1`,
+		},
+		{
+			stmt:     `SELECT :_x1, '🐰', :name`,
+			expStmt:  `SELECT ?, '🐰', ?`,
+			expNames: []string{"_x1", "name"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.stmt, func(t *testing.T) {
+			stmt, names, err := statements.RewriteNamed(c.stmt, 0, isArg)
+			xt.OK(t, err)
+			xt.Eq(t, c.expStmt, stmt)
+			xt.Eq(t, c.expNames, names)
+		})
+	}
+
+	t.Run("@name is user variable without isArg", func(t *testing.T) {
+		stmt, names, err := statements.RewriteNamed(`SELECT @id, :id`, 0, nil)
+		xt.OK(t, err)
+		xt.Eq(t, `SELECT @id, ?`, stmt)
+		xt.Eq(t, []string{"id"}, names)
+	})
+
+	t.Run("mixing named and positional", func(t *testing.T) {
+		_, _, err := statements.RewriteNamed(`SELECT ?, :id`, 0, nil)
+		xt.KO(t, err)
+		xt.Eq(t, "mixing named and positional placeholders not supported", err.Error())
+	})
+}
+
+func TestBindNamed(t *testing.T) {
+	t.Run("same name more than once", func(t *testing.T) {
+		args, err := statements.BindNamed([]string{"id", "name", "id"}, map[string]any{"id": 1, "name": "Gopher"})
+		xt.OK(t, err)
+		xt.Eq(t, []any{1, "Gopher", 1}, args)
+	})
+
+	t.Run("missing argument", func(t *testing.T) {
+		_, err := statements.BindNamed([]string{"id", "name"}, map[string]any{"id": 1})
+		xt.KO(t, err)
+		xt.Eq(t, "missing argument for named placeholder 'name'", err.Error())
+	})
+
+	t.Run("argument not used", func(t *testing.T) {
+		_, err := statements.BindNamed([]string{"id"}, map[string]any{"id": 1, "name": "Gopher"})
+		xt.KO(t, err)
+		xt.Eq(t, "named argument 'name' not used", err.Error())
+	})
+}
+
+func TestUniqueNames(t *testing.T) {
+	xt.Eq(t, []string{"b", "a"}, statements.UniqueNames([]string{"b", "a", "b", "a"}))
+}
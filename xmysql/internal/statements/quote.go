@@ -3,26 +3,156 @@
 package statements
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/golistic/pxmysql/decimal"
+)
+
+// backslashEscaper escapes like the MySQL C API function mysql_real_escape_string.
+var backslashEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"'", `\'`,
+	`"`, `\"`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
 )
 
+// unsafeCharsets are the character sets in which a multibyte character can
+// contain the byte 0x5c, which is the backslash.
+var unsafeCharsets = map[string]bool{
+	"big5":    true,
+	"cp932":   true,
+	"gb18030": true,
+	"gbk":     true,
+	"sjis":    true,
+}
+
+// EscapableCharset returns whether values can be safely quoted when the client
+// uses the character set with the given name. An empty name is considered
+// to be the default utf8mb4.
+func EscapableCharset(name string) bool {
+	return !unsafeCharsets[strings.ToLower(name)]
+}
+
 // QuoteValue quotes p so that it can be safely used to substituted placeholders
 // within a SQL query.
 func QuoteValue(p any) (string, error) {
+	return quoteValue(p, 0)
+}
+
+// quoteValue quotes p like QuoteValue. When mode has NoBackslashEscapes, single
+// quotes within strings are doubled instead of escaped using backslash.
+// Arguments implementing driver.Valuer are resolved first, and types not known
+// are handled using their underlying kind. Values of time.Time are used as is;
+// the caller is responsible for converting to the correct location.
+func quoteValue(p any, mode Mode) (string, error) {
 
 	switch v := p.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
 	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
 		return fmt.Sprintf("%d", v), nil
 	case []byte:
-		return fmt.Sprintf("_binary'%x'", v), nil
-	case float32, float64:
-		return fmt.Sprintf("%f", v), nil
+		if v == nil {
+			return "NULL", nil
+		}
+		return fmt.Sprintf("X'%x'", v), nil
+	case float32:
+		return quoteFloat(float64(v), 32)
+	case float64:
+		return quoteFloat(v, 64)
 	case string:
-		return "'" + strings.ReplaceAll(v, "'", `\'`) + "'", nil
-	default:
-		return "", fmt.Errorf("cannot quote parameter with value type %T", p)
+		return quoteString(v, mode), nil
+	case decimal.Decimal:
+		return v.String(), nil
+	case *decimal.Decimal:
+		if v == nil {
+			return "NULL", nil
+		}
+		return v.String(), nil
+	case time.Time:
+		return quoteString(v.Format("2006-01-02 15:04:05.999999"), mode), nil
+	case []string:
+		return quoteString(strings.Join(v, ","), mode), nil
+	}
+
+	rv := reflect.ValueOf(p)
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return "NULL", nil
+	}
+
+	if v, ok := p.(driver.Valuer); ok {
+		value, err := v.Value()
+		if err != nil {
+			return "", err
+		}
+		if _, ok := value.(driver.Valuer); ok {
+			return "", fmt.Errorf("value of driver.Valuer %T is driver.Valuer (%T)", p, value)
+		}
+		return quoteValue(value, mode)
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		return quoteValue(rv.Elem().Interface(), mode)
+	case reflect.Bool:
+		return quoteValue(rv.Bool(), mode)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return quoteValue(rv.Int(), mode)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return quoteValue(rv.Uint(), mode)
+	case reflect.Float32:
+		return quoteFloat(rv.Float(), 32)
+	case reflect.Float64:
+		return quoteFloat(rv.Float(), 64)
+	case reflect.String:
+		return quoteString(rv.String(), mode), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return quoteValue(rv.Bytes(), mode)
+		}
+	}
+
+	if v, ok := p.(json.Marshaler); ok {
+		doc, err := v.MarshalJSON()
+		if err != nil {
+			return "", fmt.Errorf("marshalling %T (%w)", p, err)
+		}
+		return quoteString(string(doc), mode), nil
+	}
+
+	return "", fmt.Errorf("cannot quote parameter with value type %T", p)
+}
+
+// quoteString returns s as string literal. When mode has NoBackslashEscapes,
+// single quotes are doubled, otherwise special characters are escaped using
+// backslash.
+func quoteString(s string, mode Mode) string {
+	if mode&NoBackslashEscapes != 0 {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	return "'" + backslashEscaper.Replace(s) + "'"
+}
+
+func quoteFloat(f float64, bitSize int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("cannot quote floating point value %v", f)
 	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize), nil
 }
 
 func QuoteIdentifier(p string) (string, error) {
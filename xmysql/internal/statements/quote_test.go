@@ -3,11 +3,18 @@
 package statements_test
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/golistic/xgo/xstrings"
 	"github.com/golistic/xgo/xt"
 
+	"github.com/golistic/pxmysql/decimal"
+	"github.com/golistic/pxmysql/null"
 	"github.com/golistic/pxmysql/xmysql/internal/statements"
 )
 
@@ -43,4 +50,73 @@ func TestQuoteValue(t *testing.T) {
 			})
 		}
 	})
+	t.Run("strings with NO_BACKSLASH_ESCAPES", func(t *testing.T) {
+		got, err := statements.SubstitutePlaceholders("SELECT ?", statements.NoBackslashEscapes, `it's C:\`)
+		xt.OK(t, err)
+		xt.Eq(t, `SELECT 'it''s C:\'`, got)
+	})
+
+	t.Run("special characters", func(t *testing.T) {
+		got, err := statements.QuoteValue("a\x00b\nc\rd\x1ae\"f\\")
+		xt.OK(t, err)
+		xt.Eq(t, `'a\0b\nc\rd\Ze\"f\\'`, got)
+	})
+
+	t.Run("other types", func(t *testing.T) {
+		type name string
+		type level int8
+
+		var cases = []struct {
+			got any
+			exp string
+		}{
+			{got: nil, exp: "NULL"},
+			{got: true, exp: "TRUE"},
+			{got: false, exp: "FALSE"},
+			{got: -12, exp: "-12"},
+			{got: uint64(math.MaxUint64), exp: "18446744073709551615"},
+			{got: 1.5, exp: "1.5"},
+			{got: float32(0.1), exp: "0.1"},
+			{got: 1e-10, exp: "1e-10"},
+			{got: []byte("abc"), exp: "X'616263'"},
+			{got: []byte(nil), exp: "NULL"},
+			{got: *decimal.MustNew("3.140"), exp: "3.140"},
+			{got: decimal.MustNew("-1.5"), exp: "-1.5"},
+			{got: time.Date(2023, 9, 1, 13, 4, 5, 120000000, time.UTC), exp: "'2023-09-01 13:04:05.12'"},
+			{got: []string{"a", "b"}, exp: "'a,b'"},
+			{got: null.String{String: "it's", Valid: true}, exp: `'it\'s'`},
+			{got: null.Int64{}, exp: "NULL"},
+			{got: null.New(7), exp: "7"},
+			{got: (*string)(nil), exp: "NULL"},
+			{got: xstrings.Pointer("p"), exp: "'p'"},
+			{got: name("Gopher"), exp: "'Gopher'"},
+			{got: level(-3), exp: "-3"},
+			{got: json.RawMessage(`{"a":1}`), exp: "X'7b2261223a317d'"},
+		}
+
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%T", c.got), func(t *testing.T) {
+				got, err := statements.QuoteValue(c.got)
+				xt.OK(t, err)
+				xt.Eq(t, c.exp, got)
+			})
+		}
+	})
+
+	t.Run("unsupported values", func(t *testing.T) {
+		_, err := statements.QuoteValue(math.NaN())
+		xt.KO(t, err)
+
+		_, err = statements.QuoteValue(struct{}{})
+		xt.KO(t, err)
+		xt.Eq(t, "cannot quote parameter with value type struct {}", err.Error())
+	})
+}
+
+func TestEscapableCharset(t *testing.T) {
+	xt.Assert(t, statements.EscapableCharset(""))
+	xt.Assert(t, statements.EscapableCharset("utf8mb4"))
+	xt.Assert(t, statements.EscapableCharset("latin1"))
+	xt.Assert(t, !statements.EscapableCharset("gbk"))
+	xt.Assert(t, !statements.EscapableCharset("SJIS"))
 }
@@ -32,11 +32,111 @@ func TestPlaceholderIndexes(t *testing.T) {
 			stmt: `SELECT ?, '?', "?", ?, "'?'", ?`,
 			exp:  []int{7, 20, 30},
 		},
+		{
+			stmt: "SELECT `?`, `a``?`, ?",
+			exp:  []int{20},
+		},
+		{
+			stmt: "SELECT ? -- ?\n, ? # ?\n, ?",
+			exp:  []int{7, 16, 24},
+		},
+		{
+			stmt: "SELECT 1--?\n, ?",
+			exp:  []int{10, 14},
+		},
+		{
+			stmt: `SELECT /* ? */ ?, /*+ ? */ ?`,
+			exp:  []int{15, 27},
+		},
+		{
+			stmt: `SELECT /*!80000 ?, */ ?`,
+			exp:  []int{16, 22},
+		},
+		{
+			stmt: `SELECT 'it\'s ?', 'it''s ?', ?`,
+			exp:  []int{29},
+		},
+		{
+			stmt: `SELECT 'back\\', ?`,
+			exp:  []int{17},
+		},
+		{
+			stmt: `SELECT '🐰', ?, 'ñ?', ?`,
+			exp:  []int{15, 25},
+		},
+		{
+			stmt: `SELECT '/* ?', ?, ' */ ?'`,
+			exp:  []int{15},
+		},
+		{
+			stmt: `SELECT ? /* not closed ?`,
+			exp:  []int{7},
+		},
 	}
 
 	for _, c := range cases {
-		t.Run("", func(t *testing.T) {
-			xt.Eq(t, c.exp, statements.PlaceholderIndexes(statements.Placeholder, c.stmt))
+		t.Run(c.stmt, func(t *testing.T) {
+			xt.Eq(t, c.exp, statements.PlaceholderIndexes(c.stmt, 0))
 		})
 	}
+
+	t.Run("NO_BACKSLASH_ESCAPES", func(t *testing.T) {
+		stmt := `SELECT 'C:\', ?, '\?'`
+		xt.Eq(t, []int{19}, statements.PlaceholderIndexes(stmt, 0))
+		xt.Eq(t, []int{14}, statements.PlaceholderIndexes(stmt, statements.NoBackslashEscapes))
+	})
+
+	t.Run("ANSI_QUOTES", func(t *testing.T) {
+		stmt := `SELECT "a\", ?, "\?"`
+		xt.Eq(t, []int{18}, statements.PlaceholderIndexes(stmt, 0))
+		xt.Eq(t, []int{13}, statements.PlaceholderIndexes(stmt, statements.ANSIQuotes))
+	})
+}
+
+func TestParseSQLMode(t *testing.T) {
+	var cases = []struct {
+		sqlMode string
+		exp     statements.Mode
+	}{
+		{sqlMode: "", exp: 0},
+		{sqlMode: "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES", exp: 0},
+		{sqlMode: "NO_BACKSLASH_ESCAPES", exp: statements.NoBackslashEscapes},
+		{sqlMode: "ansi_quotes", exp: statements.ANSIQuotes},
+		{
+			sqlMode: "REAL_AS_FLOAT,PIPES_AS_CONCAT,ANSI_QUOTES,NO_BACKSLASH_ESCAPES",
+			exp:     statements.ANSIQuotes | statements.NoBackslashEscapes,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.sqlMode, func(t *testing.T) {
+			xt.Eq(t, c.exp, statements.ParseSQLMode(c.sqlMode))
+		})
+	}
+}
+
+func TestSubstitutePlaceholders(t *testing.T) {
+	t.Run("byte offsets with multi-byte characters", func(t *testing.T) {
+		got, err := statements.SubstitutePlaceholders(`SELECT '🐰?', ?, ?`, 0, "ñ", 2)
+		xt.OK(t, err)
+		xt.Eq(t, `SELECT '🐰?', 'ñ', 2`, got)
+	})
+
+	t.Run("backslash and quote are escaped", func(t *testing.T) {
+		got, err := statements.SubstitutePlaceholders(`SELECT ?`, 0, `\' OR 1=1 -- `)
+		xt.OK(t, err)
+		xt.Eq(t, `SELECT '\\\' OR 1=1 -- '`, got)
+	})
+
+	t.Run("NO_BACKSLASH_ESCAPES doubles quotes", func(t *testing.T) {
+		got, err := statements.SubstitutePlaceholders(`SELECT ?`, statements.NoBackslashEscapes, `\' OR 1=1 -- `)
+		xt.OK(t, err)
+		xt.Eq(t, `SELECT '\'' OR 1=1 -- '`, got)
+	})
+
+	t.Run("placeholders in comments are not substituted", func(t *testing.T) {
+		got, err := statements.SubstitutePlaceholders("SELECT ? /* ? */ -- ?", 0, 1)
+		xt.OK(t, err)
+		xt.Eq(t, "SELECT 1 /* ? */ -- ?", got)
+	})
 }
@@ -3,7 +3,6 @@
 package statements
 
 import (
-	"bytes"
 	"fmt"
 
 	"github.com/golistic/xgo/xmath"
@@ -12,9 +11,10 @@ import (
 const Placeholder = '?'
 
 // SubstitutePlaceholders replaces the placeholders within stmt with respective element of args.
-func SubstitutePlaceholders(stmt string, args ...any) (string, error) {
+// The mode is used to find the placeholders and to quote the values.
+func SubstitutePlaceholders(stmt string, mode Mode, args ...any) (string, error) {
 
-	placeholders := PlaceholderIndexes(Placeholder, stmt)
+	placeholders := PlaceholderIndexes(stmt, mode)
 	if len(placeholders) != len(args) {
 		return "", fmt.Errorf("need %d placeholder(s); found %d)", len(args), len(placeholders))
 	}
@@ -35,7 +35,7 @@ func SubstitutePlaceholders(stmt string, args ...any) (string, error) {
 			continue
 		}
 
-		quoted, err := QuoteValue(arg)
+		quoted, err := quoteValue(arg, mode)
 		if err != nil {
 			return "", err
 		}
@@ -54,32 +54,16 @@ func SubstitutePlaceholders(stmt string, args ...any) (string, error) {
 	return string(buf), nil
 }
 
-// PlaceholderIndexes returns the indices of all placeholders within query.
-func PlaceholderIndexes(placeholder rune, query string) []int {
-
+// PlaceholderIndexes returns the byte offsets of all placeholders within query.
+// Placeholders within string literals, quoted identifiers, and comments are
+// ignored. The mode is used to know whether backslashes escape and
+// whether double quotes quote identifiers.
+func PlaceholderIndexes(query string, mode Mode) []int {
 	var indexes []int
 
-	var quoted bool
-	var quote rune
-	for i, r := range bytes.Runes([]byte(query)) {
-		// we skip quoted so that we support queries which have placeholder in string literals
-		if r == '"' || r == '\'' {
-			if quoted && quote == r {
-				quoted = false
-				quote = 0
-				continue
-			} else if !quoted {
-				quoted = true
-				quote = r
-				continue
-			}
-		}
-
-		if quoted {
-			continue
-		}
-
-		if r == placeholder {
+	lex := newLexer(query, mode)
+	for i := lex.next(); i >= 0; i = lex.next() {
+		if query[i] == Placeholder {
 			indexes = append(indexes, i)
 		}
 	}
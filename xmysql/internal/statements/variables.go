@@ -0,0 +1,146 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package statements
+
+import (
+	"strings"
+)
+
+// sessionVariables are the targets of SET which change the variables needed
+// to find placeholders and quote values: the SQL mode and the character set
+// of the client. Names are lower case without scope.
+var sessionVariables = map[string]bool{
+	"sql_mode":             true,
+	"names":                true, // SET NAMES
+	"character":            true, // SET CHARACTER SET
+	"charset":              true, // SET CHARSET
+	"character_set_client": true,
+}
+
+// variableScopes are the keywords which can precede the name of a system
+// variable within SET.
+var variableScopes = map[string]bool{
+	"global": true, "session": true, "local": true, "persist": true, "persist_only": true,
+}
+
+// ChangesSessionState returns whether stmt is a USE statement changing the
+// default schema, or a SET statement assigning the sql_mode, or the character
+// set of the client, for example, using SET NAMES. String literals, quoted
+// identifiers, and comments are skipped, so that user variables such as
+// @names, or values mentioning sql_mode, do not count.
+func ChangesSessionState(stmt string, mode Mode) bool {
+	tokens := tokenize(stmt, mode)
+	if len(tokens) == 0 {
+		return false
+	}
+
+	switch strings.ToLower(tokens[0]) {
+	case "use":
+		return true
+	case "set":
+	default:
+		return false
+	}
+
+	var depth int
+	target := true // next token is the target of an assignment
+
+	for _, tok := range tokens[1:] {
+		switch tok {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			continue
+		case ",":
+			if depth == 0 {
+				target = true
+			}
+			continue
+		}
+
+		if !target {
+			continue
+		}
+
+		name := strings.ToLower(tok)
+		if variableScopes[name] {
+			continue
+		}
+		target = false
+
+		if n, ok := strings.CutPrefix(name, "@@"); ok {
+			name = n
+			for scope := range variableScopes {
+				if n, ok := strings.CutPrefix(name, scope+"."); ok {
+					name = n
+					break
+				}
+			}
+		} else if strings.HasPrefix(name, "@") {
+			continue // user variable
+		}
+
+		if sessionVariables[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unpreparable are the first keywords of statements which MySQL does not
+// accept as prepared statement (ER_UNSUPPORTED_PS).
+var unpreparable = map[string]bool{
+	"use": true, "lock": true, "unlock": true,
+	"prepare": true, "execute": true, "deallocate": true,
+}
+
+// Preparable returns whether stmt can be executed as server-side prepared
+// statement. Statements such as USE and LOCK TABLES cannot, and must be
+// executed as is.
+func Preparable(stmt string, mode Mode) bool {
+	tokens := tokenize(stmt, mode)
+	return len(tokens) == 0 || !unpreparable[strings.ToLower(tokens[0])]
+}
+
+// tokenize returns the words and the punctuation (commas and parentheses)
+// of the SQL code within stmt. Words include the @ and . of variables, such
+// as @@session.sql_mode. Anything else is skipped.
+func tokenize(stmt string, mode Mode) []string {
+	var tokens []string
+
+	start, prev := -1, -1
+	endWord := func() {
+		if start >= 0 {
+			tokens = append(tokens, stmt[start:prev+1])
+			start = -1
+		}
+	}
+
+	lex := newLexer(stmt, mode)
+	for i := lex.next(); i >= 0; i = lex.next() {
+		c := stmt[i]
+
+		if isNameChar(c) || c == '$' || c == '@' || c == '.' {
+			if start >= 0 && prev != i-1 { // comment or quoted text in between
+				endWord()
+			}
+			if start < 0 {
+				start = i
+			}
+			prev = i
+			continue
+		}
+
+		endWord()
+		switch c {
+		case ',', '(', ')':
+			tokens = append(tokens, string(c))
+		}
+	}
+	endWord()
+
+	return tokens
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package statements_test
+
+import (
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/xmysql/internal/statements"
+)
+
+func TestChangesSessionState(t *testing.T) {
+	var cases = map[string]bool{
+		"SET sql_mode = 'ANSI_QUOTES'":                            true,
+		"set SESSION sql_mode = ''":                               true,
+		"SET SESSION /* comment */ sql_mode = ''":                 true,
+		"SET /* comment */ SESSION\n-- comment\n sql_mode = ''":   true,
+		"SET @@sql_mode := ''":                                    true,
+		"SET @@SESSION.sql_mode = ''":                             true,
+		"SET @v = 1, @@local.sql_mode = CONCAT(@@sql_mode, ',x')": true,
+		"SET NAMES utf8mb4":                                       true,
+		"SET CHARACTER SET utf8mb4":                               true,
+		"SET CHARSET utf8mb4":                                     true,
+		"SET character_set_client = 'latin1'":                     true,
+		"/*!40101 SET NAMES utf8 */":                              true,
+		"SET @names = 1":                                          false,
+		"SET @'sql_mode' = 1":                                     false,
+		"SET @v = 'sql_mode', @w = 'names'":                       false,
+		"SET @v = (SELECT 1), @character = 2":                     false,
+		"SET TRANSACTION ISOLATION LEVEL READ COMMITTED":          false,
+		"SET collation_connection = 'utf8mb4_bin'":                false,
+		"SELECT @@sql_mode":                                       false,
+		"UPDATE t SET names = 'x'":                                false,
+		"/* SET NAMES utf8 */ SELECT 1":                           false,
+		"USE test":                                                true,
+		"use `my db`":                                             true,
+		"":                                                        false,
+	}
+
+	for stmt, exp := range cases {
+		t.Run(stmt, func(t *testing.T) {
+			xt.Eq(t, exp, statements.ChangesSessionState(stmt, 0))
+		})
+	}
+}
+
+func TestPreparable(t *testing.T) {
+	var cases = map[string]bool{
+		"SELECT 1":                  true,
+		"INSERT INTO t VALUES (?)":  true,
+		"SET @a = 1":                true,
+		"USE mysql":                 false,
+		"use `test`":                false,
+		"/* comment */ USE test":    false,
+		"LOCK TABLES t READ":        false,
+		"UNLOCK TABLES":             false,
+		"PREPARE s FROM 'SELECT 1'": false,
+		"EXECUTE s":                 false,
+		"DEALLOCATE PREPARE s":      false,
+		"SELECT 'USE test'":         true,
+		"SELECT * FROM `use`":       true,
+		"-- USE test\nSELECT 1":     true,
+	}
+
+	for stmt, exp := range cases {
+		t.Run(stmt, func(t *testing.T) {
+			xt.Eq(t, exp, statements.Preparable(stmt, 0))
+		})
+	}
+}
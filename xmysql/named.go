@@ -0,0 +1,173 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/golistic/pxmysql/xmysql/internal/statements"
+)
+
+// namedArgs holds arguments for named placeholders.
+type namedArgs struct {
+	values map[string]any
+	// caseless is true when values were taken from struct fields; keys are
+	// lower-cased and not all have to be used.
+	caseless bool
+}
+
+// namedArguments returns the arguments for named placeholders when arg is a map
+// with string keys, or a struct (or pointer to one). Struct fields are mapped
+// the same way as with Result.ScanStruct. The second return value is false when
+// arg cannot be used for named placeholders.
+func namedArguments(arg any) (*namedArgs, bool) {
+	if m, ok := arg.(map[string]any); ok {
+		return &namedArgs{values: m}, true
+	}
+
+	rv := reflect.ValueOf(arg)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		rv = rv.Elem()
+	}
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		values := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = iter.Value().Interface()
+		}
+		return &namedArgs{values: values}, true
+	case rv.Kind() == reflect.Struct && isRowStruct(rv.Type()):
+		fields := structFields(rv.Type())
+		values := make(map[string]any, len(fields))
+		for name, index := range fields {
+			values[name] = rv.FieldByIndex(index).Interface()
+		}
+		return &namedArgs{values: values, caseless: true}, true
+	default:
+		return nil, false
+	}
+}
+
+// namedValues returns the arguments for named placeholders when args are
+// driver.NamedValue or sql.NamedArg having a name. It returns nil when none
+// of args is named, and an error when named and positional arguments are mixed.
+func namedValues(args []any) (*namedArgs, error) {
+	values := map[string]any{}
+
+	for _, arg := range args {
+		var name string
+		var value any
+		switch v := arg.(type) {
+		case driver.NamedValue:
+			name, value = v.Name, v.Value
+		case sql.NamedArg:
+			name, value = v.Name, v.Value
+		}
+		if name == "" {
+			continue
+		}
+
+		if _, have := values[name]; have {
+			return nil, fmt.Errorf("named argument '%s' given more than once", name)
+		}
+		values[name] = value
+	}
+
+	switch {
+	case len(values) == 0:
+		return nil, nil
+	case len(values) != len(args):
+		return nil, fmt.Errorf("mixing named and positional arguments not supported")
+	default:
+		return &namedArgs{values: values}, nil
+	}
+}
+
+func (na *namedArgs) lookup(name string) (any, bool) {
+	if na.caseless {
+		name = strings.ToLower(name)
+	}
+	v, ok := na.values[name]
+	return v, ok
+}
+
+func (na *namedArgs) has(name string) bool {
+	_, ok := na.lookup(name)
+	return ok
+}
+
+// bind returns the arguments in the order of names, as returned by
+// statements.RewriteNamed.
+func (na *namedArgs) bind(names []string) ([]any, error) {
+	if !na.caseless {
+		return statements.BindNamed(names, na.values)
+	}
+
+	// only the fields used are arguments
+	values := map[string]any{}
+	for _, name := range names {
+		if v, ok := na.lookup(name); ok {
+			values[name] = v
+		}
+	}
+
+	return statements.BindNamed(names, values)
+}
+
+// positionalArguments resolves named arguments, given as a single map or struct,
+// or as sql.NamedArg or driver.NamedValue, rewriting the named placeholders
+// within stmt. The returned arguments are positional; driver.NamedValue
+// are unwrapped.
+func (ses *Session) positionalArguments(stmt string, args []any) (string, []any, error) {
+	named, err := namedValues(args)
+	if err != nil {
+		return "", nil, err
+	}
+	if named == nil && len(args) == 1 {
+		named, _ = namedArguments(args[0])
+	}
+
+	if named == nil {
+		values := make([]any, len(args))
+		for i, arg := range args {
+			if nv, ok := arg.(driver.NamedValue); ok {
+				arg = nv.Value
+			}
+			values[i] = arg
+		}
+		return stmt, values, nil
+	}
+
+	stmt, names, err := statements.RewriteNamed(stmt, ses.sqlMode, named.has)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if args, err = named.bind(names); err != nil {
+		return "", nil, err
+	}
+
+	return stmt, args, nil
+}
+
+// ExpandSlices returns stmt in which each placeholder bound to a slice is replaced
+// with as many placeholders as the slice has elements, together with the arguments
+// with the slices replaced by their elements. For example, `IN (?)` with []int{1, 2}
+// becomes `IN (?, ?)`. Named arguments are resolved first, so the returned
+// statement only has positional placeholders.
+// Slices of bytes and values implementing driver.Valuer are not expanded. It is
+// an error to pass an empty slice.
+func (ses *Session) ExpandSlices(stmt string, args ...any) (string, []any, error) {
+	stmt, args, err := ses.positionalArguments(stmt, args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return statements.ExpandSlices(stmt, ses.sqlMode, args)
+}
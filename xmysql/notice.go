@@ -15,6 +15,7 @@ type StateChanges struct {
 	RowsAffected      uint64
 	CurrentSchema     string
 	ProducedMessage   string
+	AccountExpired    bool
 }
 
 type notices struct {
@@ -70,6 +71,8 @@ func (n *notices) add(msg *network.ServerMessage) error {
 			if len(m.Value) > 0 {
 				n.stateChanges.ProducedMessage = string(m.Value[0].VString.Value)
 			}
+		case mysqlxnotice.SessionStateChanged_ACCOUNT_EXPIRED:
+			n.stateChanges.AccountExpired = true
 		case mysqlxnotice.SessionStateChanged_CLIENT_ID_ASSIGNED:
 			if len(m.Value) > 0 {
 				n.stateChanges.ClientID = m.Value[0].GetVUnsignedInt()
@@ -0,0 +1,236 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golistic/pxmysql/xmysql/internal/optionfile"
+)
+
+// LoginPathFileEnv is the environment variable which, when set, is used
+// instead of the default location of the login path file.
+const LoginPathFileEnv = "MYSQL_TEST_LOGIN_FILE"
+
+// defaultOptionGroups are the groups read when none are given.
+var defaultOptionGroups = []string{"client", "mysqlx"}
+
+// LoadOptionFile reads the MySQL option file path, such as my.cnf, and returns
+// the configuration using the options of groups. When groups are not given,
+// the groups client and mysqlx are used; options of later groups override
+// earlier ones. Files referenced using !include and !includedir are read as
+// well. Options not related to connecting, like those of other programs,
+// are ignored.
+//
+// The port and socket of the X Plugin are taken from the options mysqlx-port
+// and mysqlx-socket. The options port and socket, which usually are those of
+// the classic protocol, are only used from the mysqlx group.
+func LoadOptionFile(path string, groups ...string) (*ConnectConfig, error) {
+	g, err := optionfile.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		groups = defaultOptionGroups
+	}
+
+	return configFromOptions(g, lowerAll(groups))
+}
+
+// LoadLoginPath reads the login path name from the obfuscated login path file
+// created by mysql_config_editor and returns the configuration. Options of the
+// client login path are used as defaults. When name is empty, only the client
+// login path is used.
+// The file is read from the location given by the environment variable
+// MYSQL_TEST_LOGIN_FILE, or otherwise from its default location (see LoginPathFile).
+// Like with LoadOptionFile, the port and socket stored by mysql_config_editor
+// are those of the classic protocol and are not used.
+func LoadLoginPath(name string) (*ConnectConfig, error) {
+	path, err := LoginPathFile()
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := optionfile.ReadLogin(path)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.ToLower(name)
+	groups := []string{"client"}
+	if name != "" && name != "client" {
+		if _, ok := g[name]; !ok {
+			return nil, fmt.Errorf("login path %s not found in %s", name, path)
+		}
+		groups = append(groups, name)
+	}
+
+	return configFromOptions(g, groups)
+}
+
+// LoginPathFile returns the location of the login path file. This is the
+// value of the environment variable MYSQL_TEST_LOGIN_FILE when set; otherwise
+// it is .mylogin.cnf within the home directory, or, on Windows,
+// MySQL\.mylogin.cnf within the APPDATA directory.
+func LoginPathFile() (string, error) {
+	if p := os.Getenv(LoginPathFileEnv); p != "" {
+		return p, nil
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "MySQL", ".mylogin.cnf"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed getting login path file (%w)", err)
+	}
+
+	return filepath.Join(home, ".mylogin.cnf"), nil
+}
+
+// configFromOptions returns the configuration using the options of groups
+// read from option files.
+func configFromOptions(g optionfile.Groups, groups []string) (*ConnectConfig, error) {
+	cfg := &ConnectConfig{
+		AuthMethod: AuthMethodAuto,
+	}
+
+	invalid := func(name, value string) error {
+		return fmt.Errorf("invalid value for option %s (was %s)", name, value)
+	}
+
+	options := g.Options(groups...)
+	for _, name := range []string{"port", "socket"} {
+		delete(options, name)
+		delete(options, "mysqlx-"+name)
+		if option, value, ok := xProtocolOption(g, groups, name); ok {
+			options[option] = value
+		}
+	}
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var host, port string
+	var err error
+
+	for _, name := range names {
+		value := options[name]
+
+		switch name {
+		case "host":
+			host = value
+		case "port", "mysqlx-port":
+			if n, err := strconv.ParseUint(value, 10, 16); err != nil || n == 0 {
+				return nil, invalid(name, value)
+			}
+			port = value
+		case "user":
+			cfg.Username = value
+		case "password":
+			cfg.SetPassword(value)
+		case "socket", "mysqlx-socket":
+			cfg.UnixSockAddr = value
+		case "database":
+			cfg.Schema = value
+		case "ssl-mode":
+			if cfg.SSLMode, err = ParseSSLMode(value); err != nil {
+				return nil, invalid(name, value)
+			}
+			cfg.UseTLS = cfg.SSLMode != SSLModeDisabled
+		case "ssl-ca":
+			cfg.TLSServerCACertPath = value
+		case "ssl-cert":
+			cfg.TLSClientCertPath = value
+		case "ssl-key":
+			cfg.TLSClientKeyPath = value
+		case "ssl-crl":
+			cfg.TLSCRLPath = value
+		case "tls-version":
+			for _, n := range strings.Split(value, ",") {
+				v, err := ParseTLSVersion(strings.TrimSpace(n))
+				if err != nil {
+					return nil, invalid(name, value)
+				}
+				if cfg.TLSMinVersion == 0 || v < cfg.TLSMinVersion {
+					cfg.TLSMinVersion = v
+				}
+				if v > cfg.TLSMaxVersion {
+					cfg.TLSMaxVersion = v
+				}
+			}
+		case "connect-timeout":
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return nil, invalid(name, value)
+			}
+			cfg.ConnectTimeout = time.Duration(n) * time.Second
+		}
+	}
+
+	// like MySQL clients, a CA certificate without SSL mode means VERIFY_CA
+	if cfg.SSLMode == "" && cfg.TLSServerCACertPath != "" {
+		cfg.SSLMode = SSLModeVerifyCA
+		cfg.UseTLS = true
+	}
+
+	if host != "" || port != "" {
+		if host == "" {
+			host = DefaultHost
+		}
+		if port == "" {
+			port = DefaultPort
+		}
+		cfg.Address = net.JoinHostPort(host, port)
+	}
+
+	return cfg, nil
+}
+
+// xProtocolOption returns the name and value of the option mysqlx-<name> of
+// groups. When not available, and groups includes the mysqlx group, the
+// option name of the mysqlx group is returned instead.
+func xProtocolOption(g optionfile.Groups, groups []string, name string) (string, string, bool) {
+	option := "mysqlx-" + name
+
+	var value string
+	var found bool
+	for _, group := range groups {
+		if v, ok := g[group][option]; ok {
+			value, found = v, true
+		}
+	}
+	if found {
+		return option, value, true
+	}
+
+	if slices.Contains(groups, "mysqlx") {
+		if v, ok := g["mysqlx"][name]; ok {
+			return name, v, true
+		}
+	}
+
+	return "", "", false
+}
+
+func lowerAll(s []string) []string {
+	lower := make([]string, len(s))
+	for i, v := range s {
+		lower[i] = strings.ToLower(v)
+	}
+	return lower
+}
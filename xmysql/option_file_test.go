@@ -0,0 +1,185 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql_test
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golistic/xgo/xstrings"
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/xmysql"
+	"github.com/golistic/pxmysql/xmysql/internal/optionfile"
+)
+
+func TestLoadOptionFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "my.cnf")
+
+	xt.OK(t, os.WriteFile(path, []byte(`
+[mysql]
+user = not_used
+
+[client]
+user = scott
+password = "tiger"
+host = db.example.com
+port = 3306
+database = test
+connect_timeout = 5
+!include tls.cnf
+
+[mysqlx]
+port = 33060
+
+[other]
+user = other
+`), 0600))
+
+	xt.OK(t, os.WriteFile(filepath.Join(dir, "tls.cnf"), []byte(`
+[client]
+ssl-ca = /etc/mysql/ca.pem
+tls-version = TLSv1.3,TLSv1.2
+`), 0600))
+
+	t.Run("default groups", func(t *testing.T) {
+		cfg, err := xmysql.LoadOptionFile(path)
+		xt.OK(t, err)
+
+		xt.Eq(t, &xmysql.ConnectConfig{
+			Address:             "db.example.com:33060",
+			Username:            "scott",
+			Password:            xstrings.Pointer("tiger"),
+			Schema:              "test",
+			UseTLS:              true,
+			SSLMode:             xmysql.SSLModeVerifyCA,
+			AuthMethod:          xmysql.AuthMethodAuto,
+			TLSServerCACertPath: "/etc/mysql/ca.pem",
+			TLSMinVersion:       tls.VersionTLS12,
+			TLSMaxVersion:       tls.VersionTLS13,
+			ConnectTimeout:      5 * time.Second,
+		}, cfg)
+	})
+
+	t.Run("given groups", func(t *testing.T) {
+		cfg, err := xmysql.LoadOptionFile(path, "client", "Other")
+		xt.OK(t, err)
+		xt.Eq(t, "other", cfg.Username)
+		xt.Eq(t, "db.example.com:33060", cfg.Address) // classic port of client not used
+	})
+
+	t.Run("X Plugin port and socket", func(t *testing.T) {
+		var cases = map[string]struct {
+			content string
+			groups  []string
+			exp     *xmysql.ConnectConfig
+		}{
+			"mysqlx options in client": {
+				content: "[client]\nport = 3306\nmysqlx-port = 33070\nsocket = /tmp/mysql.sock\n" +
+					"mysqlx_socket = /tmp/mysqlx.sock\n",
+				exp: &xmysql.ConnectConfig{
+					Address:      "127.0.0.1:33070",
+					UnixSockAddr: "/tmp/mysqlx.sock",
+				},
+			},
+			"mysqlx options preferred over mysqlx group": {
+				content: "[client]\nmysqlx-port = 33070\n[mysqlx]\nport = 33080\nsocket = /tmp/mysqlx.sock\n",
+				exp: &xmysql.ConnectConfig{
+					Address:      "127.0.0.1:33070",
+					UnixSockAddr: "/tmp/mysqlx.sock",
+				},
+			},
+			"classic options ignored": {
+				content: "[client]\nport = 3306\nsocket = /tmp/mysql.sock\n",
+				exp:     &xmysql.ConnectConfig{},
+			},
+			"mysqlx group not given": {
+				content: "[client]\nuser = scott\n[mysqlx]\nport = 33080\n",
+				groups:  []string{"client"},
+				exp:     &xmysql.ConnectConfig{Username: "scott"},
+			},
+		}
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				p := filepath.Join(t.TempDir(), "my.cnf")
+				xt.OK(t, os.WriteFile(p, []byte(c.content), 0600))
+
+				cfg, err := xmysql.LoadOptionFile(p, c.groups...)
+				xt.OK(t, err)
+				c.exp.AuthMethod = xmysql.AuthMethodAuto
+				xt.Eq(t, c.exp, cfg)
+			})
+		}
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		var cases = map[string]string{
+			"port":            "x",
+			"mysqlx-port":     "0",
+			"ssl-mode":        "sometimes",
+			"tls-version":     "TLSv1.1",
+			"connect-timeout": "soon",
+		}
+
+		for option, value := range cases {
+			t.Run(option, func(t *testing.T) {
+				p := filepath.Join(t.TempDir(), "my.cnf")
+				xt.OK(t, os.WriteFile(p, []byte("[mysqlx]\n"+option+" = "+value+"\n"), 0600))
+
+				_, err := xmysql.LoadOptionFile(p)
+				xt.KO(t, err)
+				xt.Eq(t, "invalid value for option "+option+" (was "+value+")", err.Error())
+			})
+		}
+	})
+}
+
+func TestLoadLoginPath(t *testing.T) {
+	data, err := optionfile.EncryptLogin([]byte("[client]\nuser = \"root\"\npassword = \"secret\"\n" +
+		"[remote]\nuser = \"scott\"\nhost = \"db.example.com\"\nport = 3306\n" +
+		"[xremote]\nhost = \"db.example.com\"\nmysqlx-port = 33070\n"))
+	xt.OK(t, err)
+
+	path := filepath.Join(t.TempDir(), ".mylogin.cnf")
+	xt.OK(t, os.WriteFile(path, data, 0600))
+	t.Setenv(xmysql.LoginPathFileEnv, path)
+
+	t.Run("login path file from environment", func(t *testing.T) {
+		p, err := xmysql.LoginPathFile()
+		xt.OK(t, err)
+		xt.Eq(t, path, p)
+	})
+
+	t.Run("client", func(t *testing.T) {
+		cfg, err := xmysql.LoadLoginPath("")
+		xt.OK(t, err)
+		xt.Eq(t, "root", cfg.Username)
+		xt.Eq(t, "secret", *cfg.Password)
+		xt.Eq(t, "", cfg.Address)
+	})
+
+	t.Run("login path using client as defaults", func(t *testing.T) {
+		cfg, err := xmysql.LoadLoginPath("remote")
+		xt.OK(t, err)
+		xt.Eq(t, "scott", cfg.Username)
+		xt.Eq(t, "secret", *cfg.Password)
+		xt.Eq(t, "db.example.com:33060", cfg.Address) // classic port not used
+	})
+
+	t.Run("login path with X Plugin port", func(t *testing.T) {
+		cfg, err := xmysql.LoadLoginPath("xremote")
+		xt.OK(t, err)
+		xt.Eq(t, "db.example.com:33070", cfg.Address)
+	})
+
+	t.Run("login path not available", func(t *testing.T) {
+		_, err := xmysql.LoadLoginPath("nope")
+		xt.KO(t, err)
+		xt.Eq(t, "login path nope not found in "+path, err.Error())
+	})
+}
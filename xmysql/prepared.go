@@ -5,13 +5,16 @@ package xmysql
 import (
 	"context"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/golistic/pxmysql/decimal"
 	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxdatatypes"
 	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxprepare"
+	"github.com/golistic/pxmysql/xmysql/internal/statements"
 	"github.com/golistic/pxmysql/xmysql/xproto"
 )
 
@@ -19,105 +22,41 @@ type Prepared struct {
 	session         *Session
 	result          *Result
 	numPlaceholders int
+	names           []string
+	changesVars     bool
 }
 
 // Execute the prepared statements replacing placeholders with args.
+// When the statement has named placeholders, args is either a single map with
+// string keys or a struct (see Session.ExecuteStatement), or args are all
+// sql.NamedArg or driver.NamedValue with a name.
 func (p *Prepared) Execute(ctx context.Context, args ...any) (*Result, error) {
 	if p.session == nil || p.result == nil || p.result.stmtID == 0 {
 		return nil, fmt.Errorf("not initialized")
 	}
 
-	pArgs := make([]*mysqlxdatatypes.Any, len(args))
+	named, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	if named == nil && len(p.names) > 0 && len(args) == 1 {
+		named, _ = namedArguments(args[0])
+	}
 
-	for i, arg := range args {
-		var err error
-
-		var a any
-		switch v := arg.(type) {
-		case driver.NamedValue:
-			a = v.Value
-		default:
-			a = arg
+	switch {
+	case named != nil && len(p.names) == 0:
+		return nil, fmt.Errorf("named arguments used but statement has no named placeholders")
+	case named != nil:
+		if args, err = named.bind(p.names); err != nil {
+			return nil, err
 		}
+	}
 
-		// ridiculous type-switch; preventing using reflection
-		switch v := a.(type) {
-		case nil:
-			pArgs[i] = xproto.Nil()
-		case bool:
-			pArgs[i] = xproto.Bool(v)
-		case *bool:
-			pArgs[i] = xproto.Bool(*v)
-		case int:
-			pArgs[i] = xproto.SignedInt(v)
-		case int8:
-			pArgs[i] = xproto.SignedInt(v)
-		case int16:
-			pArgs[i] = xproto.SignedInt(v)
-		case int32:
-			pArgs[i] = xproto.SignedInt(v)
-		case int64:
-			pArgs[i] = xproto.SignedInt(v)
-		case uint:
-			pArgs[i] = xproto.UnsignedInt(v)
-		case uint8:
-			pArgs[i] = xproto.UnsignedInt(v)
-		case uint16:
-			pArgs[i] = xproto.UnsignedInt(v)
-		case uint32:
-			pArgs[i] = xproto.UnsignedInt(v)
-		case uint64:
-			pArgs[i] = xproto.UnsignedInt(v)
-		case *int:
-			pArgs[i] = xproto.SignedInt(*v)
-		case *int8:
-			pArgs[i] = xproto.SignedInt(*v)
-		case *int16:
-			pArgs[i] = xproto.SignedInt(*v)
-		case *int32:
-			pArgs[i] = xproto.SignedInt(*v)
-		case *int64:
-			pArgs[i] = xproto.SignedInt(*v)
-		case *uint:
-			pArgs[i] = xproto.UnsignedInt(*v)
-		case *uint8:
-			pArgs[i] = xproto.UnsignedInt(*v)
-		case *uint16:
-			pArgs[i] = xproto.UnsignedInt(*v)
-		case *uint32:
-			pArgs[i] = xproto.UnsignedInt(*v)
-		case *uint64:
-			pArgs[i] = xproto.UnsignedInt(*v)
-		case string:
-			pArgs[i] = xproto.String(v)
-		case *string:
-			pArgs[i] = xproto.String(v)
-		case []byte:
-			pArgs[i] = xproto.Bytes(v)
-		case float32:
-			pArgs[i] = xproto.Float32(v)
-		case *float32:
-			pArgs[i] = xproto.Float32(*v)
-		case float64:
-			pArgs[i] = xproto.Float64(v)
-		case *float64:
-			pArgs[i] = xproto.Float64(*v)
-		case decimal.Decimal:
-			pArgs[i] = xproto.Decimal(v)
-		case *decimal.Decimal:
-			pArgs[i] = xproto.Decimal(*v)
-		case time.Time:
-			if pArgs[i], err = xproto.Time(v, p.session.TimeLocation().String()); err != nil {
-				return nil, err
-			}
-		case *time.Time:
-			if pArgs[i], err = xproto.Time(*v, p.session.TimeLocation().String()); err != nil {
-				return nil, err
-			}
-		case []string:
-			pArgs[i] = xproto.String(strings.Join(v, ","))
-		default:
-			return nil, fmt.Errorf("argument type '%T' not supported", a)
+	pArgs := make([]*mysqlxdatatypes.Any, len(args))
+
+	for i, arg := range args {
+		if pArgs[i], err = p.argument(arg); err != nil {
+			return nil, fmt.Errorf("argument %d (%w)", i+1, err)
 		}
 	}
 
@@ -135,11 +74,163 @@ func (p *Prepared) Execute(ctx context.Context, args ...any) (*Result, error) {
 		return nil, err
 	}
 
+	if p.changesVars {
+		if err := p.session.refreshSessionVariables(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	return res, nil
 }
 
+// argument encodes arg as X Protocol value. Arguments implementing driver.Valuer
+// are resolved first; types not known are handled using their underlying kind,
+// and values implementing json.Marshaler are stored as JSON text.
+func (p *Prepared) argument(arg any) (*mysqlxdatatypes.Any, error) {
+	if v, ok := arg.(driver.NamedValue); ok {
+		arg = v.Value
+	}
+
+	// nil pointers of any type, including those handled by the type-switch
+	// and those implementing driver.Valuer, are NULL
+	rv := reflect.ValueOf(arg)
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return xproto.Nil(), nil
+	}
+
+	// ridiculous type-switch; preventing using reflection
+	switch v := arg.(type) {
+	case nil:
+		return xproto.Nil(), nil
+	case bool:
+		return xproto.Bool(v), nil
+	case *bool:
+		return xproto.Bool(*v), nil
+	case int:
+		return xproto.SignedInt(v), nil
+	case int8:
+		return xproto.SignedInt(v), nil
+	case int16:
+		return xproto.SignedInt(v), nil
+	case int32:
+		return xproto.SignedInt(v), nil
+	case int64:
+		return xproto.SignedInt(v), nil
+	case uint:
+		return xproto.UnsignedInt(v), nil
+	case uint8:
+		return xproto.UnsignedInt(v), nil
+	case uint16:
+		return xproto.UnsignedInt(v), nil
+	case uint32:
+		return xproto.UnsignedInt(v), nil
+	case uint64:
+		return xproto.UnsignedInt(v), nil
+	case *int:
+		return xproto.SignedInt(*v), nil
+	case *int8:
+		return xproto.SignedInt(*v), nil
+	case *int16:
+		return xproto.SignedInt(*v), nil
+	case *int32:
+		return xproto.SignedInt(*v), nil
+	case *int64:
+		return xproto.SignedInt(*v), nil
+	case *uint:
+		return xproto.UnsignedInt(*v), nil
+	case *uint8:
+		return xproto.UnsignedInt(*v), nil
+	case *uint16:
+		return xproto.UnsignedInt(*v), nil
+	case *uint32:
+		return xproto.UnsignedInt(*v), nil
+	case *uint64:
+		return xproto.UnsignedInt(*v), nil
+	case string:
+		return xproto.String(v), nil
+	case *string:
+		return xproto.String(v), nil
+	case []byte:
+		return xproto.Bytes(v), nil
+	case float32:
+		return xproto.Float32(v), nil
+	case *float32:
+		return xproto.Float32(*v), nil
+	case float64:
+		return xproto.Float64(v), nil
+	case *float64:
+		return xproto.Float64(*v), nil
+	case decimal.Decimal:
+		return xproto.Decimal(v), nil
+	case *decimal.Decimal:
+		return xproto.Decimal(*v), nil
+	case time.Time:
+		return xproto.Time(v, p.session.TimeLocation().String())
+	case *time.Time:
+		return xproto.Time(*v, p.session.TimeLocation().String())
+	case []string:
+		return xproto.String(strings.Join(v, ",")), nil
+	}
+
+	if v, ok := arg.(driver.Valuer); ok {
+		value, err := v.Value()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := value.(driver.Valuer); ok {
+			return nil, fmt.Errorf("value of driver.Valuer %T is driver.Valuer (%T)", arg, value)
+		}
+		return p.argument(value)
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		return p.argument(rv.Elem().Interface())
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return xproto.Bool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return xproto.SignedInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return xproto.UnsignedInt(rv.Uint()), nil
+	case reflect.Float32:
+		return xproto.Float32(float32(rv.Float())), nil
+	case reflect.Float64:
+		return xproto.Float64(rv.Float()), nil
+	case reflect.String:
+		return xproto.String(rv.String()), nil
+	case reflect.Slice:
+		switch rv.Type().Elem().Kind() {
+		case reflect.Uint8:
+			return xproto.Bytes(rv.Bytes()), nil
+		case reflect.String:
+			if _, ok := arg.(json.Marshaler); !ok {
+				elems := make([]string, rv.Len())
+				for i := range elems {
+					elems[i] = rv.Index(i).String()
+				}
+				return xproto.String(strings.Join(elems, ",")), nil
+			}
+		}
+	}
+
+	if v, ok := arg.(json.Marshaler); ok {
+		doc, err := v.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshalling %T (%w)", arg, err)
+		}
+		return xproto.String(string(doc)), nil
+	}
+
+	return nil, fmt.Errorf("argument type '%T' not supported", arg)
+}
+
 // Deallocate makes this prepared statement not usable any longer.
 func (p *Prepared) Deallocate(ctx context.Context) error {
+	if p.session == nil || p.result == nil {
+		return fmt.Errorf("not initialized")
+	}
 	return p.session.DeallocatePrepareStatement(ctx, p.result.stmtID)
 }
 
@@ -152,3 +243,10 @@ func (p *Prepared) StatementID() uint32 {
 func (p *Prepared) NumPlaceholders() int {
 	return p.numPlaceholders
 }
+
+// NamedPlaceholders returns the names of the named placeholders in the order
+// they first appear within the statement. Names used more than once are
+// returned only once.
+func (p *Prepared) NamedPlaceholders() []string {
+	return statements.UniqueNames(p.names)
+}
@@ -4,6 +4,8 @@ package xmysql_test
 
 import (
 	"context"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -77,6 +79,69 @@ func TestPrepared_Execute(t *testing.T) {
 		}
 	})
 
+	t.Run("driver.Valuer and underlying types", func(t *testing.T) {
+		stmt := "SELECT ?"
+		prep, err := ses.PrepareStatement(context.Background(), stmt)
+		xt.OK(t, err)
+
+		type name string
+		type count uint16
+
+		var cases = []any{
+			null.String{String: "str", Valid: true},
+			null.String{},
+			&null.Int64{Int64: 3, Valid: true},
+			(*null.Int64)(nil),
+			null.New(time.Now()),
+			null.Of[float64]{},
+			name("Gopher"),
+			count(9),
+			json.RawMessage(`{"x":1}`),
+		}
+
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%T", c), func(t *testing.T) {
+				_, err := prep.Execute(context.Background(), c)
+				xt.OK(t, err)
+			})
+		}
+	})
+
+	t.Run("nil typed pointers are NULL", func(t *testing.T) {
+		prep, err := ses.PrepareStatement(context.Background(), "SELECT ? IS NULL")
+		xt.OK(t, err)
+
+		var cases = []any{
+			(*bool)(nil),
+			(*int)(nil),
+			(*int8)(nil),
+			(*int16)(nil),
+			(*int32)(nil),
+			(*int64)(nil),
+			(*uint)(nil),
+			(*uint8)(nil),
+			(*uint16)(nil),
+			(*uint32)(nil),
+			(*uint64)(nil),
+			(*string)(nil),
+			(*float32)(nil),
+			(*float64)(nil),
+			(*decimal.Decimal)(nil),
+			(*time.Time)(nil),
+			(*null.String)(nil),
+			driver.NamedValue{Ordinal: 1, Value: (*decimal.Decimal)(nil)},
+		}
+
+		for _, c := range cases {
+			t.Run(fmt.Sprintf("%T", c), func(t *testing.T) {
+				res, err := prep.Execute(context.Background(), c)
+				xt.OK(t, err)
+				xt.Eq(t, 1, len(res.Rows))
+				xt.Eq(t, int64(1), res.Rows[0].Values[0])
+			})
+		}
+	})
+
 	numCols := []string{"bit_", "bool_", "tinyint_", "tinyint_unsigned", "smallint_", "smallint_unsigned",
 		"mediumint_", "mediumint_unsigned", "int_", "int_unsigned",
 		"bigint_", "bigint_unsigned",
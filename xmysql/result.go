@@ -20,8 +20,6 @@ import (
 	"github.com/golistic/pxmysql/xmysql/internal/network"
 )
 
-const flagNotNull = 0x0010
-
 type doneWhenFunc = func(r *Result) bool
 
 type Row struct {
@@ -55,9 +53,10 @@ type Result struct {
 
 	Row             *Row
 	Rows            []*Row
-	Columns         []*mysqlxresultset.ColumnMetaData
+	Columns         []*Column
 	ProducedMessage string
 	stmtID          uint32
+	rowIndex        int
 }
 
 func handleResult(ctx context.Context, ses *Session, doneWhen doneWhenFunc) (*Result, error) {
@@ -85,7 +84,7 @@ func handleResult(ctx context.Context, ses *Session, doneWhen doneWhenFunc) (*Re
 		case mysqlx.ServerMessages_OK:
 			result.ok = true
 		case mysqlx.ServerMessages_ERROR:
-			return nil, mysqlerrors.NewFromServerMessage(msg)
+			return nil, wrapServerError(mysqlerrors.NewFromServerMessage(msg))
 		case mysqlx.ServerMessages_CONN_CAPABILITIES:
 			result.serverCapabilities, err = NewServerCapabilitiesFromMessage(msg)
 			if err != nil {
@@ -108,7 +107,7 @@ func handleResult(ctx context.Context, ses *Session, doneWhen doneWhenFunc) (*Re
 			if err := msg.Unmarshall(m); err != nil {
 				return nil, fmt.Errorf("failed unmarshalling '%s' (%w)", msgType.String(), err)
 			}
-			result.Columns = append(result.Columns, m)
+			result.Columns = append(result.Columns, newColumn(m))
 		case mysqlx.ServerMessages_RESULTSET_ROW:
 			if err := result.readRow(ctx, msg); err != nil {
 				return nil, err
@@ -204,6 +203,12 @@ func (rs *Result) FetchRow(ctx context.Context) error {
 	}
 }
 
+// useNullOf returns whether the session was configured to use the generic
+// null.Of[T] for nullable columns.
+func (rs *Result) useNullOf() bool {
+	return rs.session != nil && rs.session.config.UseNullOf
+}
+
 func (rs *Result) readRow(ctx context.Context, msg *network.ServerMessage) error {
 	if msg == nil {
 		panic("serverMessage cannot be nil")
@@ -231,21 +236,21 @@ func (rs *Result) readRow(ctx context.Context, msg *network.ServerMessage) error
 	return nil
 }
 
-func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxresultset.ColumnMetaData) (any, error) {
+func (rs *Result) decodeValue(ctx context.Context, value []byte, column *Column) (any, error) {
 	var goValue any
 	valid := len(value) > 0
 
 	if network.TraceValues {
 		var notNull string
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			notNull = " NOT NULL"
 		}
 		fmt.Printf("[%s%s]\n", column.Type.String(), notNull)
 		fmt.Print(hex.Dump(value))
 	}
 
-	switch *column.Type {
-	case mysqlxresultset.ColumnMetaData_SINT:
+	switch column.Type {
+	case ColumnTypeSInt:
 		var v int64
 		if len(value) > 0 {
 			var n int
@@ -255,8 +260,10 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[int64]{V: v, Valid: valid}
 		} else {
 			goValue = null.Int64{
 				Int64: v,
@@ -264,7 +271,7 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_UINT:
+	case ColumnTypeUInt:
 		var v uint64
 		if len(value) > 0 {
 			var n int
@@ -274,8 +281,10 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[uint64]{V: v, Valid: valid}
 		} else {
 			goValue = null.Uint64{
 				Uint64: v,
@@ -283,14 +292,16 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_DOUBLE:
+	case ColumnTypeDouble:
 		var v float64
 		if len(value) > 0 {
 			v = math.Float64frombits(binary.LittleEndian.Uint64(value))
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[float64]{V: v, Valid: valid}
 		} else {
 			goValue = null.Float64{
 				Float64: v,
@@ -298,14 +309,16 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_FLOAT:
+	case ColumnTypeFloat:
 		var v float32
 		if len(value) > 0 {
 			v = math.Float32frombits(binary.LittleEndian.Uint32(value))
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[float32]{V: v, Valid: valid}
 		} else {
 			goValue = null.Float32{
 				Float32: v,
@@ -313,16 +326,16 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_BYTES, mysqlxresultset.ColumnMetaData_ENUM:
-		_, isString := collationIDs[column.GetCollation()]
-
-		if isString {
+	case ColumnTypeBytes, ColumnTypeEnum:
+		if column.hasCharacterSet() {
 			var v string
 			if valid {
 				v = string(value[:len(value)-1])
 			}
-			if column.GetFlags()&flagNotNull > 0 {
+			if column.NotNull() {
 				goValue = v
+			} else if rs.useNullOf() {
+				goValue = null.Of[string]{V: v, Valid: valid}
 			} else {
 				goValue = null.String{
 					String: v,
@@ -334,8 +347,10 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			if valid {
 				v = value[:len(value)-1]
 			}
-			if column.GetFlags()&flagNotNull > 0 {
+			if column.NotNull() {
 				goValue = v
+			} else if rs.useNullOf() {
+				goValue = null.Of[[]byte]{V: v, Valid: valid}
 			} else {
 				goValue = null.Bytes{
 					Bytes: v,
@@ -344,7 +359,7 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_TIME:
+	case ColumnTypeTime:
 		var v time.Duration
 
 		if len(value) > 0 {
@@ -371,8 +386,10 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			v = time.Duration(t)
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[time.Duration]{V: v, Valid: valid}
 		} else {
 			goValue = null.Duration{
 				Duration: v,
@@ -380,7 +397,7 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_DATETIME:
+	case ColumnTypeDatetime:
 		var v time.Time
 		if len(value) > 0 {
 			parts := [7]int{}
@@ -422,8 +439,10 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 				parts[6], ContextTimeLocation(ctx))
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[time.Time]{V: v, Valid: valid}
 		} else {
 			goValue = null.Time{
 				Time:  v,
@@ -431,7 +450,7 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_SET:
+	case ColumnTypeSet:
 		var v []string
 		emptySet := valid && (value[0] == 0x01 && len(value) == 1)
 
@@ -443,8 +462,10 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[[]string]{V: v, Valid: valid}
 		} else {
 			goValue = null.Strings{
 				Strings: v,
@@ -452,7 +473,7 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_BIT:
+	case ColumnTypeBit:
 		var v uint64
 
 		if valid {
@@ -462,8 +483,10 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[uint64]{V: v, Valid: valid}
 		} else {
 			goValue = null.Uint64{
 				Uint64: v,
@@ -471,7 +494,7 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-	case mysqlxresultset.ColumnMetaData_DECIMAL:
+	case ColumnTypeDecimal:
 		var v decimal.Decimal
 
 		if valid {
@@ -482,8 +505,10 @@ func (rs *Result) decodeValue(ctx context.Context, value []byte, column *mysqlxr
 			}
 		}
 
-		if column.GetFlags()&flagNotNull > 0 {
+		if column.NotNull() {
 			goValue = v
+		} else if rs.useNullOf() {
+			goValue = null.Of[decimal.Decimal]{V: v, Valid: valid}
 		} else {
 			goValue = null.Decimal{
 				Decimal: v,
@@ -11,6 +11,7 @@ import (
 	"github.com/golistic/xgo/xt"
 
 	"github.com/golistic/pxmysql/internal/xxt"
+	"github.com/golistic/pxmysql/null"
 	"github.com/golistic/pxmysql/xmysql"
 )
 
@@ -71,3 +72,103 @@ func TestResult_FetchRow(t *testing.T) {
 		xt.Assert(t, mUse.DiffAlloc() < 35000)
 	})
 }
+
+func TestResult_Scan(t *testing.T) {
+	config := &xmysql.ConnectConfig{
+		Address:  testContext.XPluginAddr,
+		Username: xxt.UserNative,
+	}
+	config.SetPassword(xxt.UserNativePwd)
+
+	ses, err := xmysql.GetSession(context.Background(), config)
+	xt.OK(t, err)
+
+	q := "SELECT 1 AS id, 'Sakila' AS name, CAST(NULL AS CHAR) AS nickname " +
+		"UNION SELECT 2, 'Go gopher', 'Gopher'"
+
+	t.Run("scan into variables", func(t *testing.T) {
+		res, err := ses.ExecuteStatement(context.Background(), q)
+		xt.OK(t, err)
+
+		var id int
+		var name string
+		var nickname *string
+
+		xt.Assert(t, res.Next())
+		xt.OK(t, res.Scan(&id, &name, &nickname))
+		xt.Eq(t, 1, id)
+		xt.Eq(t, "Sakila", name)
+		xt.Eq(t, (*string)(nil), nickname)
+
+		xt.Assert(t, res.Next())
+		xt.OK(t, res.Scan(&id, &name, &nickname))
+		xt.Eq(t, 2, id)
+		xt.Eq(t, "Gopher", *nickname)
+
+		xt.Assert(t, !res.Next())
+	})
+
+	t.Run("scan NULL into non-pointer fails", func(t *testing.T) {
+		res, err := ses.ExecuteStatement(context.Background(), q)
+		xt.OK(t, err)
+
+		var id int
+		var name, nickname string
+
+		xt.Assert(t, res.Next())
+		xt.KO(t, res.Scan(&id, &name, &nickname))
+	})
+
+	t.Run("scan into struct", func(t *testing.T) {
+		type person struct {
+			ID       uint64          `db:"id"`
+			Name     string          `mysql:"name"`
+			Nickname null.Of[string] // matched by name
+		}
+
+		res, err := ses.ExecuteStatement(context.Background(), q)
+		xt.OK(t, err)
+
+		var p person
+		xt.Assert(t, res.Next())
+		xt.OK(t, res.ScanStruct(&p))
+		xt.Eq(t, 1, p.ID)
+		xt.Eq(t, "Sakila", p.Name)
+		xt.Assert(t, !p.Nickname.Valid)
+	})
+
+	t.Run("collect", func(t *testing.T) {
+		type person struct {
+			ID       int     `db:"id"`
+			Name     string  `db:"name"`
+			Nickname *string `db:"nickname"`
+		}
+
+		res, err := ses.ExecuteStatement(context.Background(), q)
+		xt.OK(t, err)
+
+		persons, err := xmysql.Collect[person](context.Background(), res)
+		xt.OK(t, err)
+		xt.Eq(t, 2, len(persons))
+		xt.Eq(t, "Go gopher", persons[1].Name)
+
+		res, err = ses.ExecuteStatement(context.Background(), "SELECT 'a' UNION SELECT 'b'")
+		xt.OK(t, err)
+
+		names, err := xmysql.Collect[string](context.Background(), res)
+		xt.OK(t, err)
+		xt.Eq(t, []string{"a", "b"}, names)
+	})
+
+	t.Run("collect fails when column has no field", func(t *testing.T) {
+		type person struct {
+			ID int `db:"id"`
+		}
+
+		res, err := ses.ExecuteStatement(context.Background(), q)
+		xt.OK(t, err)
+
+		_, err = xmysql.Collect[person](context.Background(), res)
+		xt.KO(t, err)
+	})
+}
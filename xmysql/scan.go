@@ -0,0 +1,222 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golistic/pxmysql/decimal"
+	"github.com/golistic/pxmysql/internal/convert"
+)
+
+// structTagNames are the struct tags, in order of precedence, used to map
+// columns to fields when scanning into structs.
+var structTagNames = []string{"db", "mysql"}
+
+var structFieldsCache sync.Map // reflect.Type -> map[string][]int
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+)
+
+// Next makes the next buffered row the current row, available as Result.Row,
+// so it can be used with Scan or ScanStruct. It returns false when there are
+// no more rows.
+func (rs *Result) Next() bool {
+	if rs.rowIndex >= len(rs.Rows) {
+		rs.Row = nil
+		return false
+	}
+
+	rs.Row = rs.Rows[rs.rowIndex]
+	rs.rowIndex++
+	return true
+}
+
+// Scan copies the values of the current row into the values pointed at by dest.
+// The number of values in dest must be the same as the number of columns.
+// Values are converted similar to how Go's database/sql does it: for example,
+// a nullable column can be scanned into a pointer, a sql.Scanner (like null.Of),
+// or into a non-pointer when the value is not NULL.
+func (rs *Result) Scan(dest ...any) error {
+	if rs.Row == nil {
+		return fmt.Errorf("scanning (no current row; use Next)")
+	}
+
+	if len(dest) != len(rs.Row.Values) {
+		return fmt.Errorf("scanning (expected %d destination arguments; not %d)",
+			len(rs.Row.Values), len(dest))
+	}
+
+	for i, value := range rs.Row.Values {
+		if err := convert.Assign(dest[i], value); err != nil {
+			return fmt.Errorf("scanning column %d %s (%w)", i, rs.columnName(i), err)
+		}
+	}
+
+	return nil
+}
+
+// ScanStruct copies the values of the current row into the fields of the struct
+// dest points to. Columns are mapped to fields using the `db` or `mysql` struct tag,
+// or, when there is no tag, the field name (case-insensitive). Fields tagged
+// with "-" are skipped. Returns an error when a column has no field.
+func (rs *Result) ScanStruct(dest any) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("scanning (destination must be pointer to struct; not %T)", dest)
+	}
+
+	indexes, err := rs.columnFieldIndexes(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	return rs.scanStruct(rv.Elem(), indexes)
+}
+
+func (rs *Result) scanStruct(rv reflect.Value, indexes [][]int) error {
+	if rs.Row == nil {
+		return fmt.Errorf("scanning (no current row; use Next)")
+	}
+
+	for i, value := range rs.Row.Values {
+		f := rv.FieldByIndex(indexes[i])
+		if err := convert.Assign(f.Addr().Interface(), value); err != nil {
+			return fmt.Errorf("scanning column %d %s (%w)", i, rs.columnName(i), err)
+		}
+	}
+
+	return nil
+}
+
+// columnFieldIndexes returns for each column the index of the field within
+// struct type rt.
+func (rs *Result) columnFieldIndexes(rt reflect.Type) ([][]int, error) {
+	fields := structFields(rt)
+
+	indexes := make([][]int, len(rs.Columns))
+	for i := range rs.Columns {
+		name := rs.columnName(i)
+		index, ok := fields[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("scanning (no field in %s for column %s)", rt, name)
+		}
+		indexes[i] = index
+	}
+
+	return indexes, nil
+}
+
+func (rs *Result) columnName(i int) string {
+	if i < len(rs.Columns) {
+		return rs.Columns[i].Name
+	}
+	return ""
+}
+
+// structFields returns the (lower-cased) column names mapped to the index of
+// the fields of the struct type rt. Fields of embedded structs are included.
+func structFields(rt reflect.Type) map[string][]int {
+	if v, ok := structFieldsCache.Load(rt); ok {
+		return v.(map[string][]int)
+	}
+
+	fields := map[string][]int{}
+
+	var walk func(rt reflect.Type, parent []int)
+	walk = func(rt reflect.Type, parent []int) {
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			index := append(append([]int{}, parent...), i)
+
+			var name string
+			for _, tagName := range structTagNames {
+				if tag, ok := f.Tag.Lookup(tagName); ok {
+					name, _, _ = strings.Cut(tag, ",")
+					break
+				}
+			}
+
+			switch {
+			case name == "-":
+				continue
+			case f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct:
+				walk(f.Type, index)
+				continue
+			case !f.IsExported():
+				continue
+			case name == "":
+				name = f.Name
+			}
+
+			name = strings.ToLower(name)
+			if _, have := fields[name]; !have || len(index) < len(fields[name]) {
+				fields[name] = index
+			}
+		}
+	}
+	walk(rt, nil)
+
+	structFieldsCache.Store(rt, fields)
+	return fields
+}
+
+// isRowStruct returns whether rt is a struct which fields are mapped to columns,
+// and not a value like time.Time or null.String.
+func isRowStruct(rt reflect.Type) bool {
+	return rt.Kind() == reflect.Struct &&
+		rt != timeType && rt != decimalType &&
+		!rt.Implements(valuerType) && !reflect.PointerTo(rt).Implements(scannerType)
+}
+
+// Collect returns all rows of result as a slice of T. When T is a struct, rows
+// are scanned using Result.ScanStruct, otherwise the result must have exactly
+// one column which is scanned into T.
+func Collect[T any](ctx context.Context, result *Result) ([]T, error) {
+	if result == nil {
+		return nil, fmt.Errorf("collecting (no result)")
+	}
+
+	var indexes [][]int
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	isStruct := isRowStruct(rt)
+	if isStruct {
+		var err error
+		if indexes, err = result.columnFieldIndexes(rt); err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]T, 0, len(result.Rows))
+	for result.rowIndex = 0; result.Next(); {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var item T
+		var err error
+		if isStruct {
+			err = result.scanStruct(reflect.ValueOf(&item).Elem(), indexes)
+		} else {
+			err = result.Scan(&item)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
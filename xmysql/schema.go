@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/golistic/pxmysql/null"
 	"github.com/golistic/pxmysql/xmysql/collection"
 	"github.com/golistic/pxmysql/xmysql/xproto"
 )
@@ -169,12 +168,12 @@ func (s *Schema) objectNames(ctx context.Context, kind ObjectKind) ([]string, er
 			continue
 		}
 
-		name, ok := row.Values[0].(null.String)
-		if !ok || !name.Valid {
+		name, valid := nullStringValue(row.Values[0])
+		if !valid {
 			continue
 		}
 
-		names = append(names, name.String)
+		names = append(names, name)
 	}
 
 	if len(names) == 0 {
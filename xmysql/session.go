@@ -12,6 +12,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -45,8 +46,15 @@ type Session struct {
 	usedAuthMethod     AuthMethodType
 	maxAllowedPacket   int
 	preparedStmtCount  uint32
+	address            string
+	username           string
 	password           string
 	timeLocation       *time.Location
+	sqlMode            statements.Mode
+	sqlModeName        string
+	charsetClient      string
+	epoch              uint64
+	passwordExpired    bool
 }
 
 // GetSession instantiates a new session object connecting with given config and
@@ -80,6 +88,7 @@ func NewSession(config *ConnectConfig) (*Session, error) {
 
 	ses := &Session{
 		config:            cfg,
+		username:          cfg.Username,
 		password:          password,
 		defaultSchemaName: cfg.Schema,
 		activeSchemaName:  cfg.Schema,
@@ -104,16 +113,33 @@ func NewSession(config *ConnectConfig) (*Session, error) {
 		}
 		ses.config.UnixSockAddr = f
 	} else {
-		h, p, err := net.SplitHostPort(ses.config.Address)
-		var addrErr *net.AddrError
-		if errors.As(err, &addrErr) {
-			h = ses.config.Address // on error h is empty
-			p = DefaultPort
-		}
-		if h == "" {
-			h = DefaultHost
+		ses.config.Address = normalizeAddress(ses.config.Address)
+	}
+
+	if err := ses.normalizeHosts(); err != nil {
+		return nil, err
+	}
+
+	if ses.config.SSLMode != "" {
+		if _, err := ParseSSLMode(string(ses.config.SSLMode)); err != nil {
+			return nil, err
 		}
-		ses.config.Address = net.JoinHostPort(h, p)
+	}
+
+	if ses.config.TLSMaxVersion != 0 && ses.config.TLSMinVersion > ses.config.TLSMaxVersion {
+		return nil, fmt.Errorf("minimum TLS version is higher than maximum")
+	}
+
+	if err := checkConnectionAttributes(ses.config.ConnectionAttributes); err != nil {
+		return nil, err
+	}
+
+	if err := normalizeCompression(&ses.config); err != nil {
+		return nil, err
+	}
+
+	if err := checkCapabilities(ses.config.Capabilities); err != nil {
+		return nil, err
 	}
 
 	if ses.config.AuthMethod == "" {
@@ -155,11 +181,22 @@ func (ses *Session) TimeLocation() *time.Location {
 }
 
 // IsReachable returns whether the configured MySQL instance is available.
+// When hosts are configured, it returns whether any of them is available.
 func (ses *Session) IsReachable() bool {
-	c, _ := net.DialTimeout("tcp", ses.config.Address, time.Second)
-	if c != nil {
-		_ = c.Close()
-		return true
+	addresses := []string{ses.config.Address}
+	if len(ses.config.Hosts) > 0 {
+		addresses = addresses[:0]
+		for _, h := range ses.config.Hosts {
+			addresses = append(addresses, h.Address)
+		}
+	}
+
+	for _, addr := range addresses {
+		c, _ := net.DialTimeout("tcp", addr, time.Second)
+		if c != nil {
+			_ = c.Close()
+			return true
+		}
 	}
 
 	return false
@@ -193,18 +230,34 @@ func (ses *Session) Close() error {
 }
 
 // Write writes protobuf msg using this session's connection to the server.
+// When ctx has no deadline, the configured WriteTimeout is used.
 func (ses *Session) Write(ctx context.Context, msg proto.Message) error {
+	if _, ok := ctx.Deadline(); !ok && ses.config.WriteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ses.config.WriteTimeout)
+		defer cancel()
+	}
 	return network.Write(ctx, ses.conn, msg, ses.maxAllowedPacket)
 }
 
 // Read reads a protobuf message using this session's connection to the server.
+// When ctx has no deadline, the configured ReadTimeout is used.
 func (ses *Session) Read(ctx context.Context) (*network.ServerMessage, error) {
+	if _, ok := ctx.Deadline(); !ok && ses.config.ReadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ses.config.ReadTimeout)
+		defer cancel()
+	}
 	return network.Read(ctx, ses.conn)
 }
 
 // UsesTLS returns whether this session uses TLS.
 func (ses *Session) UsesTLS() bool {
-	_, ok := ses.conn.(*tls.Conn)
+	conn := ses.conn
+	if c, ok := conn.(*network.CompressedConn); ok {
+		conn = c.NetConn()
+	}
+	_, ok := conn.(*tls.Conn)
 	return ok
 }
 
@@ -224,18 +277,50 @@ func (ses *Session) ExecCommand(ctx context.Context, name string, args *mysqlxda
 	})
 }
 
+// ExecuteStatement executes stmt substituting the placeholders with args.
+// Named placeholders, :name or @name, are used when args is a single map with
+// string keys or a struct; struct fields are mapped like with Result.ScanStruct.
+// The same name can be used more than once. The form @name is only a placeholder
+// when there is an argument with that name, otherwise it is a user variable.
+// When the configuration has ExpandSlices set, slice arguments are expanded
+// (see Session.ExpandSlices).
 func (ses *Session) ExecuteStatement(ctx context.Context, stmt string, args ...any) (*Result, error) {
 	if len(args) > 0 {
 		var err error
-		stmt, err = statements.SubstitutePlaceholders(stmt, args...)
+		if ses.config.ExpandSlices {
+			stmt, args, err = ses.ExpandSlices(stmt, args...)
+		} else {
+			stmt, args, err = ses.positionalArguments(stmt, args)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !ses.InterpolationSafe() {
+			return nil, fmt.Errorf("cannot substitute placeholders using character set %s "+
+				"(use prepared statements)", ses.charsetClient)
+		}
+
+		// like with prepared statements, times are sent using the session's location
+		loc := ses.TimeLocation()
+		if loc == nil {
+			loc = time.UTC
+		}
+		for i, arg := range args {
+			if args[i], err = timeInLocation(arg, loc); err != nil {
+				return nil, err
+			}
+		}
+
+		stmt, err = statements.SubstitutePlaceholders(stmt, ses.sqlMode, args...)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if err := network.Write(ctx, ses.conn, &mysqlxsql.StmtExecute{
+	if err := ses.Write(ctx, &mysqlxsql.StmtExecute{
 		Stmt: []byte(stmt),
-	}, ses.maxAllowedPacket); err != nil {
+	}); err != nil {
 		return nil, fmt.Errorf("failed writing statement execution (%w)", err)
 	}
 
@@ -246,9 +331,114 @@ func (ses *Session) ExecuteStatement(ctx context.Context, stmt string, args ...a
 		return nil, err
 	}
 
+	if statements.ChangesSessionState(stmt, ses.sqlMode) {
+		if err := ses.refreshSessionVariables(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	return res, nil
 }
 
+// refreshSessionVariables retrieves the SQL mode and character set of the
+// session, which are needed to find placeholders and quote values, and the
+// active schema, which can be changed using USE.
+func (ses *Session) refreshSessionVariables(ctx context.Context) error {
+	res, err := ses.ExecuteStatement(ctx,
+		"SELECT @@SESSION.sql_mode, @@SESSION.character_set_client, DATABASE()")
+	if err != nil {
+		return fmt.Errorf("failed getting session variables (%w)", err)
+	}
+
+	if len(res.Rows) == 1 {
+		ses.setSQLMode(stringValue(res.Rows[0].Values[0]))
+		ses.charsetClient = stringValue(res.Rows[0].Values[1])
+		ses.activeSchemaName = stringValue(res.Rows[0].Values[2])
+	}
+
+	return nil
+}
+
+// timeInLocation returns arg with its time converted to loc when arg is a
+// time.Time, or a driver.Valuer, such as null.Time, whose value is a time.Time.
+// Other arguments, including nil pointers, are returned as is.
+func timeInLocation(arg any, loc *time.Location) (any, error) {
+	if rv := reflect.ValueOf(arg); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return arg, nil
+	}
+
+	switch v := arg.(type) {
+	case time.Time:
+		return v.In(loc), nil
+	case *time.Time:
+		return v.In(loc), nil
+	case driver.Valuer:
+		value, err := v.Value()
+		if err != nil {
+			return nil, err
+		}
+		if tm, ok := value.(time.Time); ok {
+			return tm.In(loc), nil
+		}
+	}
+
+	return arg, nil
+}
+
+// stringValue returns value as string when it is a (nullable) string as
+// found in results.
+func stringValue(value any) string {
+	s, _ := nullStringValue(value)
+	return s
+}
+
+// nullStringValue returns value as string, and whether it is valid, when it
+// is a (nullable) string as found in results. Results use null.String, or
+// null.Of[string] when the configuration has UseNullOf set.
+func nullStringValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case null.String:
+		return v.String, v.Valid
+	case null.Of[string]:
+		return v.V, v.Valid
+	default:
+		return "", false
+	}
+}
+
+// nullInt64Value returns value as int64, and whether it is valid, when it is
+// a (nullable) signed integer as found in results.
+func nullInt64Value(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case null.Int64:
+		return v.Int64, v.Valid
+	case null.Of[int64]:
+		return v.V, v.Valid
+	default:
+		return 0, false
+	}
+}
+
+// Preparable returns whether stmt can be executed as server-side prepared
+// statement, for example, using PrepareStatement. MySQL does not accept some
+// statements, such as USE or LOCK TABLES, as prepared statement; these are
+// executed using ExecuteStatement.
+func (ses *Session) Preparable(stmt string) bool {
+	return statements.Preparable(stmt, ses.sqlMode)
+}
+
+// InterpolationSafe returns whether placeholders can safely be substituted
+// client-side, for example when using Session.ExecuteStatement with arguments.
+// This is not the case when the character set of the client is one in which
+// the backslash can be part of a multibyte character, such as GBK or SJIS.
+func (ses *Session) InterpolationSafe() bool {
+	return statements.EscapableCharset(ses.charsetClient)
+}
+
 func (ses *Session) nextStmtID() uint32 {
 	return atomic.AddUint32(&ses.preparedStmtCount, 1)
 }
@@ -256,10 +446,31 @@ func (ses *Session) nextStmtID() uint32 {
 // PrepareStatement prepares the statement and returns an instance of Prepared which
 // contains the Result instance.
 // The ID of the prepared statement can be retrieved using Result.PreparedStatementID().
+// Named placeholders of the form :name are rewritten to positional ones; see
+// Prepared.Execute on how to pass arguments for them.
 func (ses *Session) PrepareStatement(ctx context.Context, statement string) (*Prepared, error) {
+	return ses.PrepareNamed(ctx, statement)
+}
+
+// PrepareNamed prepares the statement like PrepareStatement, but also treats @name
+// as named placeholder when name is one of argNames. Otherwise, @name is a
+// MySQL user variable.
+func (ses *Session) PrepareNamed(ctx context.Context, statement string, argNames ...string) (*Prepared, error) {
+	statement, names, err := statements.RewriteNamed(statement, ses.sqlMode, func(name string) bool {
+		for _, n := range argNames {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	stmtID := ses.nextStmtID()
 
-	if err := network.Write(ctx, ses.conn, &mysqlxprepare.Prepare{
+	if err := ses.Write(ctx, &mysqlxprepare.Prepare{
 		StmtId: &stmtID,
 		Stmt: &mysqlxprepare.Prepare_OneOfMessage{
 			Type: mysqlxprepare.Prepare_OneOfMessage_STMT.Enum(),
@@ -267,7 +478,7 @@ func (ses *Session) PrepareStatement(ctx context.Context, statement string) (*Pr
 				Stmt: []byte(statement),
 			},
 		},
-	}, ses.maxAllowedPacket); err != nil {
+	}); err != nil {
 		return nil, err
 	}
 
@@ -282,14 +493,16 @@ func (ses *Session) PrepareStatement(ctx context.Context, statement string) (*Pr
 	return &Prepared{
 		session:         ses,
 		result:          res,
-		numPlaceholders: len(statements.PlaceholderIndexes(statements.Placeholder, statement)),
+		numPlaceholders: len(statements.PlaceholderIndexes(statement, ses.sqlMode)),
+		names:           names,
+		changesVars:     statements.ChangesSessionState(statement, ses.sqlMode),
 	}, nil
 }
 
 func (ses *Session) DeallocatePrepareStatement(ctx context.Context, stmtID uint32) error {
-	if err := network.Write(ctx, ses.conn, &mysqlxprepare.Deallocate{
+	if err := ses.Write(ctx, &mysqlxprepare.Deallocate{
 		StmtId: &stmtID,
-	}, ses.maxAllowedPacket); err != nil {
+	}); err != nil {
 		return err
 	}
 
@@ -321,15 +534,14 @@ func (ses *Session) Collation(ctx context.Context) (*Collation, error) {
 		return nil, fmt.Errorf("failed getting collation of connection (no data)")
 	}
 
-	name := res.Rows[0].Values[0].(null.String)
-	if !name.Valid {
+	name, valid := nullStringValue(res.Rows[0].Values[0])
+	if !valid {
 		return nil, fmt.Errorf("failed getting collation of connection (name was invalid)")
 	}
-	c, ok := Collations[name.String]
+	c, ok := Collations[name]
 	if !ok {
-		v := res.Rows[0].Values[1].(null.String)
 		return nil, fmt.Errorf("failed getting collation of connection (unsupported '%s'; MySQL v%s)",
-			name.String, v.String)
+			name, stringValue(res.Rows[0].Values[1]))
 	}
 	return &c, err
 }
@@ -360,11 +572,8 @@ func (ses *Session) TimeZone(ctx context.Context) (*time.Location, error) {
 		return nil, fmt.Errorf("failed getting time zone information (too many rows)")
 	}
 
-	if res.Rows[0].Values[0] != nil {
-		s := res.Rows[0].Values[0].(null.String)
-		if s.Valid {
-			return time.LoadLocation(s.String)
-		}
+	if s, valid := nullStringValue(res.Rows[0].Values[0]); valid {
+		return time.LoadLocation(s)
 	}
 
 	return nil, fmt.Errorf("failed getting time zone information (no data)")
@@ -441,11 +650,11 @@ func (ses *Session) Schemas(ctx context.Context) ([]*Schema, error) {
 
 	schemas := make([]*Schema, len(res.Rows))
 	for i, row := range res.Rows {
-		name, ok := row.Values[0].(null.String)
-		if !ok || !name.Valid {
+		name, valid := nullStringValue(row.Values[0])
+		if !valid {
 			continue
 		}
-		schemas[i], err = newSchema(ses, name.String)
+		schemas[i], err = newSchema(ses, name)
 		if err != nil {
 			return nil, fmt.Errorf("getting schemas (%w)", err)
 		}
@@ -488,18 +697,30 @@ func (ses *Session) DropSchema(ctx context.Context, name string) error {
 // Open opens the connection to the MySQL server. This method is called by
 // GetSession, but not NewSession.
 func (ses *Session) Open(ctx context.Context) error {
-	var err error
+	if err := ses.loadCredentials(ctx); err != nil {
+		return err
+	}
+
+	switch {
+	case len(ses.config.Hosts) > 0:
+		return ses.openHosts(ctx)
+	case ses.config.UnixSockAddr != "":
+		return ses.openAddress(ctx, "unix", ses.config.UnixSockAddr)
+	default:
+		return ses.openAddress(ctx, "tcp", ses.config.Address)
+	}
+}
 
-	networkKind := "tcp"
-	address := ses.config.Address
+// openAddress opens the connection to the MySQL server at address using
+// the network kind, which is tcp or unix.
+func (ses *Session) openAddress(ctx context.Context, networkKind, address string) (err error) {
 	errCode := mysqlerrors.ClientBadTCPSocket
-	if ses.config.UnixSockAddr != "" {
-		networkKind = "unix"
-		address = ses.config.UnixSockAddr
+	if networkKind == "unix" {
 		errCode = mysqlerrors.ClientBadUnixSocket
 	}
+	ses.address = address
 
-	ses.conn, err = new(net.Dialer).DialContext(ctx, networkKind, address)
+	ses.conn, err = (&net.Dialer{Timeout: ses.config.ConnectTimeout}).DialContext(ctx, networkKind, address)
 	var opErr *net.OpError
 	if errors.As(err, &opErr) {
 		return mysqlerrors.New(errCode, opErr.Addr,
@@ -544,6 +765,42 @@ func (ses *Session) Open(ctx context.Context) error {
 		return err
 	}
 
+	// with an expired password, only the password can be changed
+	if !ses.passwordExpired {
+		if err = ses.initialize(ctx); err != nil {
+			return err
+		}
+	}
+
+	atomic.AddUint64(&ses.epoch, 1)
+
+	return err
+}
+
+// loadCredentials sets the username and password using the credentials
+// provider of the configuration, if any.
+func (ses *Session) loadCredentials(ctx context.Context) error {
+	if ses.config.CredentialsProvider == nil {
+		return nil
+	}
+
+	user, password, err := ses.config.CredentialsProvider(ctx)
+	if err != nil {
+		return fmt.Errorf("getting credentials (%w)", err)
+	}
+
+	if user == "" {
+		user = ses.config.Username
+	}
+	ses.username = user
+	ses.password = password
+
+	return nil
+}
+
+// initialize retrieves information about the server and session, and sets
+// the time zone and collation after authenticating.
+func (ses *Session) initialize(ctx context.Context) error {
 	if err := ses.metaInformation(ctx); err != nil {
 		return err
 	}
@@ -552,70 +809,186 @@ func (ses *Session) Open(ctx context.Context) error {
 		return err
 	}
 
-	return err
+	if ses.config.Collation != "" {
+		if err := ses.SetCollation(ctx, ses.config.Collation); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (ses *Session) negotiate(ctx context.Context) error {
-	if ses.config.UseTLS {
-		if err := network.Write(ctx, ses.conn, &mysqlxconnection.CapabilitiesSet{
-			Capabilities: &mysqlxconnection.Capabilities{
-				Capabilities: []*mysqlxconnection.Capability{{
-					Name:  proto.String("tls"),
-					Value: xproto.Bool(true),
-				}},
-			},
-		}, ses.maxAllowedPacket); err != nil {
-			return fmt.Errorf("failed setting capabilities (%w)", err)
-		}
+// PasswordExpired returns whether the session was opened using an expired
+// password, which is only possible using ConnectConfig.AllowExpiredPassword.
+// Until the password is changed using ChangePassword, statements fail with
+// ErrPasswordExpired.
+func (ses *Session) PasswordExpired() bool {
+	return ses.passwordExpired
+}
 
-		_, err := ses.handleResult(ctx, func(r *Result) bool {
-			return r.ok
-		})
-		if err != nil {
+// ChangePassword changes the password of the account used by the session.
+// When the password had expired, the session is fully initialized afterwards.
+func (ses *Session) ChangePassword(ctx context.Context, password string) error {
+	// the server substitutes the placeholder, so no need to know the SQL mode
+	if err := ses.Write(ctx, &mysqlxsql.StmtExecute{
+		Stmt: []byte("ALTER USER USER() IDENTIFIED BY ?"),
+		Args: []*mysqlxdatatypes.Any{xproto.String(password)},
+	}); err != nil {
+		return fmt.Errorf("failed writing statement execution (%w)", err)
+	}
+
+	if _, err := ses.handleResult(ctx, func(r *Result) bool {
+		return r.stmtOK
+	}); err != nil {
+		return fmt.Errorf("failed changing password (%w)", err)
+	}
+
+	ses.password = password
+
+	if ses.passwordExpired {
+		ses.passwordExpired = false
+		if err := ses.initialize(ctx); err != nil {
 			return err
 		}
+	}
 
-		// if TLS is supported, we got an OK, and we do the TLS handshake
-		var tlsConfig *tls.Config
-		if ses.config.TLSServerCACertPath != "" {
-			if err := network.AddServerCACertFromFile(ses.config.TLSServerCACertPath); err != nil {
-				return err
-			}
-			tlsConfig = &tls.Config{
-				InsecureSkipVerify: false, // explicit to make clear
-				RootCAs:            network.ServerCAPool,
-				ServerName:         ses.serverHostname(),
-			}
-		} else {
-			tlsConfig = &tls.Config{
-				InsecureSkipVerify: true,
-			}
-		}
-		ses.conn = tls.Client(ses.conn, tlsConfig)
-		if err := ses.conn.(*tls.Conn).Handshake(); err != nil {
-			_ = ses.conn.Close()
-			return fmt.Errorf("failed handshake (%w)", err)
+	return nil
+}
+
+// Reset resets the state of the session on the server while keeping it open and
+// authenticated. User variables, temporary tables, and prepared statements are
+// gone; the time zone and collation are set again using the configuration.
+func (ses *Session) Reset(ctx context.Context) error {
+	if err := ses.Write(ctx, &mysqlxsession.Reset{KeepOpen: proto.Bool(true)}); err != nil {
+		return fmt.Errorf("failed resetting session (%w)", err)
+	}
+
+	if _, err := ses.handleResult(ctx, func(r *Result) bool {
+		return r.ok
+	}); err != nil {
+		return err
+	}
+
+	atomic.AddUint64(&ses.epoch, 1)
+
+	if err := ses.SetTimeZone(ctx, ses.timeLocation.String()); err != nil {
+		return err
+	}
+
+	if ses.config.Collation != "" {
+		if err := ses.SetCollation(ctx, ses.config.Collation); err != nil {
+			return err
 		}
 	}
 
+	return ses.refreshSessionVariables(ctx)
+}
+
+// SQLMode returns the value of the sql_mode system variable of the session. It
+// is retrieved when the session is opened, and after executing statements
+// which change it.
+func (ses *Session) SQLMode() string {
+	return ses.sqlModeName
+}
+
+func (ses *Session) setSQLMode(name string) {
+	ses.sqlModeName = name
+	ses.sqlMode = statements.ParseSQLMode(name)
+}
+
+// Epoch returns a number which changes each time the session is opened or
+// reset. Server-side state, such as prepared statements, created within
+// another epoch is no longer available.
+func (ses *Session) Epoch() uint64 {
+	return atomic.LoadUint64(&ses.epoch)
+}
+
+func (ses *Session) negotiate(ctx context.Context) error {
 	if err := ses.getServerCapabilities(ctx); err != nil {
 		return err
 	}
 
-	return nil
+	if err := ses.startTLS(ctx); err != nil {
+		return err
+	}
+
+	if err := ses.sendConnectionAttributes(ctx); err != nil {
+		return err
+	}
+
+	if err := ses.setConfiguredCapabilities(ctx); err != nil {
+		return err
+	}
+
+	return ses.startCompression(ctx)
 }
 
-func (ses *Session) authenticate(ctx context.Context) error {
-	_, tlsOK := ses.conn.(*tls.Conn)
+// startTLS switches the connection to TLS as required by the SSL mode.
+func (ses *Session) startTLS(ctx context.Context) error {
+	mode := ses.config.EffectiveSSLMode()
+	if mode == SSLModeDisabled {
+		return nil
+	}
 
+	if !ses.serverCapabilities.TLSSupported {
+		if mode == SSLModePreferred {
+			return nil
+		}
+		return fmt.Errorf("server does not support TLS (SSL mode %s)", mode)
+	}
+
+	tlsConfig, err := ses.tlsConfig(ctx, mode)
+	if err != nil {
+		return err
+	}
+
+	if err := ses.setCapability(ctx, "tls", xproto.Bool(true)); err != nil {
+		return err
+	}
+
+	// TLS is supported and we got an OK, so we do the TLS handshake
+	ses.conn = tls.Client(ses.conn, tlsConfig)
+	if err := ses.conn.(*tls.Conn).HandshakeContext(ctx); err != nil {
+		_ = ses.conn.Close()
+		return fmt.Errorf("failed handshake (%w)", err)
+	}
+
+	// capabilities, such as authentication mechanisms, change when using TLS
+	return ses.getServerCapabilities(ctx)
+}
+
+// setCapability sets the capability name to value and waits for the server
+// to acknowledge.
+func (ses *Session) setCapability(ctx context.Context, name string, value *mysqlxdatatypes.Any) error {
+	if err := ses.Write(ctx, &mysqlxconnection.CapabilitiesSet{
+		Capabilities: &mysqlxconnection.Capabilities{
+			Capabilities: []*mysqlxconnection.Capability{{
+				Name:  proto.String(name),
+				Value: value,
+			}},
+		},
+	}); err != nil {
+		return fmt.Errorf("failed setting capabilities (%w)", err)
+	}
+
+	_, err := ses.handleResult(ctx, func(r *Result) bool {
+		return r.ok
+	})
+
+	return err
+}
+
+func (ses *Session) authenticate(ctx context.Context) error {
 	var authMethods []AuthMethodType
 	if ses.config.AuthMethod == AuthMethodAuto {
-		if ses.serverCapabilities != nil && ses.serverCapabilities.TLS {
-			authMethods = append(authMethods, AuthMethodPlain)
+		var serverMechanisms []string
+		if ses.serverCapabilities != nil {
+			serverMechanisms = ses.serverCapabilities.AuthMechanisms
+		}
+		authMethods = negotiateAuthMethods(serverMechanisms)
+		if len(authMethods) == 0 {
+			return fmt.Errorf("no authentication mechanism supported by both client and server")
 		}
-		authMethods = append(authMethods, DefaultAuthMethods()...)
-	} else if ses.usedAuthMethod == AuthMethodPlain && !tlsOK {
-		return fmt.Errorf("plain text authentication only supported over TLS")
 	} else {
 		authMethods = []AuthMethodType{ses.config.AuthMethod}
 	}
@@ -636,97 +1009,68 @@ func (ses *Session) authenticate(ctx context.Context) error {
 	return authErr
 }
 
+// authenticateWith authenticates using the mechanism method. It returns
+// whether another mechanism can be tried when authentication failed.
 func (ses *Session) authenticateWith(ctx context.Context, method AuthMethodType) (bool, error) {
 	ses.usedAuthMethod = method
 
-	if method == AuthMethodPlain {
-		return ses.authenticatePlain(ctx)
+	authenticator, ok := newAuthenticator(method)
+	if !ok {
+		return true, fmt.Errorf("unsupported authentication method '%s'", method)
 	}
 
-	// send AuthenticateStart
-	if err := network.Write(ctx, ses.conn, &mysqlxsession.AuthenticateStart{
-		MechName: proto.String(string(method)),
-	}, ses.maxAllowedPacket); err != nil {
-		return false, fmt.Errorf("failed starting authentication (%w)", err)
+	creds := AuthCredentials{
+		Username: ses.username,
+		Password: ses.password,
+		Schema:   ses.config.Schema,
+		TLS:      ses.UsesTLS(),
 	}
 
-	res, err := ses.handleResult(ctx, func(r *Result) bool {
-		return r.authChallenge != nil
-	})
+	authData, err := authenticator.Start(creds)
 	if err != nil {
-		return true, err
+		return true, err // nothing was sent; try other methods
 	}
 
-	// create authentication data
-	var authDataFunc func(authn) ([]byte, error)
-	switch method {
-	case AuthMethodMySQL41:
-		authDataFunc = authMySQL41Data
-	case AuthMethodSHA256Memory:
-		authDataFunc = authSHA256Data
-	default:
-		return true, fmt.Errorf("unsupported authentication method '%s'", method)
-	}
-
-	authData, err := authDataFunc(authn{
-		username:  ses.config.Username,
-		password:  ses.password,
-		challenge: res.authChallenge,
-		schema:    ses.config.Schema,
-	})
-	if err != nil {
-		return false, err
-	}
-
-	// send AuthenticateContinue
-	if err := network.Write(ctx, ses.conn, &mysqlxsession.AuthenticateContinue{
+	// send AuthenticateStart
+	if err := ses.Write(ctx, &mysqlxsession.AuthenticateStart{
+		MechName: proto.String(string(method)),
 		AuthData: authData,
-	}, ses.maxAllowedPacket); err != nil {
-		return false, fmt.Errorf("failed continuing authentication (%w)", err)
-	}
-
-	return ses.finishAuthentication(ctx)
-}
-
-func (ses *Session) authenticatePlain(ctx context.Context) (bool, error) {
-	_, tlsOK := ses.conn.(*tls.Conn)
-
-	if !tlsOK {
-		return true, fmt.Errorf("plain text authentication only supported over TLS")
+	}); err != nil {
+		return false, fmt.Errorf("failed starting authentication (%w)", err)
 	}
 
-	authData, err := authMySQLPlain(authn{
-		username: ses.config.Username,
-		password: ses.password,
-		schema:   ses.config.Schema,
-	})
-	if err != nil {
-		return false, err
-	}
+	for {
+		res, err := ses.handleResult(ctx, func(r *Result) bool {
+			return r.authOK || r.authChallenge != nil
+		})
+		switch {
+		case errors.Is(err, ErrPasswordExpired):
+			return false, err // other methods would fail the same way
+		case err != nil:
+			return true, err // try other methods
+		}
 
-	if err := network.Write(ctx, ses.conn, &mysqlxsession.AuthenticateStart{
-		MechName: proto.String(string(AuthMethodPlain)),
-		AuthData: authData,
-	}, ses.maxAllowedPacket); err != nil {
-		return false, err
-	}
+		if res.authOK {
+			ses.passwordExpired = res.notices.stateChanges.AccountExpired
+			return false, nil
+		}
 
-	return ses.finishAuthentication(ctx)
-}
+		authData, err := authenticator.Continue(creds, res.authChallenge)
+		if err != nil {
+			return false, err
+		}
 
-func (ses *Session) finishAuthentication(ctx context.Context) (bool, error) {
-	result, err := ses.handleResult(ctx, func(r *Result) bool {
-		return r.authOK
-	})
-	if err != nil {
-		return true, err // try other methods
+		// send AuthenticateContinue
+		if err := ses.Write(ctx, &mysqlxsession.AuthenticateContinue{
+			AuthData: authData,
+		}); err != nil {
+			return false, fmt.Errorf("failed continuing authentication (%w)", err)
+		}
 	}
-
-	return !result.authOK, nil // try other methods when needed
 }
 
 func (ses *Session) getServerCapabilities(ctx context.Context) error {
-	if err := network.Write(ctx, ses.conn, &mysqlxconnection.CapabilitiesGet{}, ses.maxAllowedPacket); err != nil {
+	if err := ses.Write(ctx, &mysqlxconnection.CapabilitiesGet{}); err != nil {
 		return fmt.Errorf("failed getting capabilities (%w)", err)
 	}
 
@@ -747,7 +1091,8 @@ func (ses *Session) metaInformation(ctx context.Context) error {
 
 	// we try to get everything in one query
 	res, err := ses.ExecuteStatement(ctx, `SELECT VERSION(), CONNECTION_ID(),
-CAST((SELECT VARIABLE_VALUE FROM performance_schema.global_variables WHERE VARIABLE_NAME = 'mysqlx_max_allowed_packet') AS SIGNED)
+CAST((SELECT VARIABLE_VALUE FROM performance_schema.global_variables WHERE VARIABLE_NAME = 'mysqlx_max_allowed_packet') AS SIGNED),
+@@SESSION.sql_mode, @@SESSION.character_set_client
 `)
 	if err != nil {
 		return err
@@ -762,10 +1107,15 @@ CAST((SELECT VARIABLE_VALUE FROM performance_schema.global_variables WHERE VARIA
 
 	// X Plugin is not returning an error when message is too big. We need to figure this
 	// out on the client side, but need to know the limit when opening the session.
-	maxAllowedPacket := res.Rows[0].Values[2].(null.Int64)
-	if maxAllowedPacket.Valid {
-		ses.maxAllowedPacket = int(maxAllowedPacket.Int64)
+	if maxAllowedPacket, valid := nullInt64Value(res.Rows[0].Values[2]); valid {
+		ses.maxAllowedPacket = int(maxAllowedPacket)
 	}
+	if n := ses.config.MaxAllowedPacket; n > 0 && (ses.maxAllowedPacket == 0 || n < ses.maxAllowedPacket) {
+		ses.maxAllowedPacket = n
+	}
+
+	ses.setSQLMode(stringValue(res.Rows[0].Values[3]))
+	ses.charsetClient = stringValue(res.Rows[0].Values[4])
 
 	return nil
 }
@@ -775,6 +1125,10 @@ func (ses *Session) handleResult(ctx context.Context, doneWhen doneWhenFunc) (*R
 }
 
 func (ses *Session) serverHostname() string {
-	host, _, _ := net.SplitHostPort(ses.config.Address) // error ignored; just return empty if not available
+	address := ses.address
+	if address == "" {
+		address = ses.config.Address
+	}
+	host, _, _ := net.SplitHostPort(address) // error ignored; just return empty if not available
 	return host
 }
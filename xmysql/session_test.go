@@ -4,10 +4,20 @@ package xmysql_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"net"
 	"os"
+	"path/filepath"
 	"slices"
 	"sort"
 	"strconv"
@@ -18,11 +28,18 @@ import (
 	"github.com/golistic/xgo/xnet"
 	"github.com/golistic/xgo/xstrings"
 	"github.com/golistic/xgo/xt"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/golistic/pxmysql/decimal"
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlx"
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxconnection"
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxdatatypes"
 	"github.com/golistic/pxmysql/internal/xxt"
+	"github.com/golistic/pxmysql/mysqlerrors"
 	"github.com/golistic/pxmysql/null"
 	"github.com/golistic/pxmysql/xmysql"
+	"github.com/golistic/pxmysql/xmysql/internal/network"
+	"github.com/golistic/pxmysql/xmysql/xproto"
 )
 
 func mustParseDuration(s string) time.Duration {
@@ -142,6 +159,24 @@ func TestNewSession(t *testing.T) {
 		xt.KO(t, err)
 		xt.Eq(t, "failed loading time location (unknown time zone Foo/Bar)", err.Error())
 	})
+
+	t.Run("invalid connection attributes", func(t *testing.T) {
+		var cases = map[string]string{
+			"_pid":                               "connection attribute name _pid is reserved",
+			"":                                   "connection attribute name cannot be empty",
+			"name_which_is_longer_than_32_bytes": "connection attribute name name_which_is_longer_than_32_bytes too long (max 32)",
+		}
+
+		for name, exp := range cases {
+			t.Run(name, func(t *testing.T) {
+				_, err := xmysql.NewSession(&xmysql.ConnectConfig{
+					ConnectionAttributes: map[string]string{name: "value"},
+				})
+				xt.KO(t, err)
+				xt.Eq(t, exp, err.Error())
+			})
+		}
+	})
 }
 
 func TestCreateSession(t *testing.T) {
@@ -173,6 +208,41 @@ func TestCreateSession(t *testing.T) {
 		// no TLS means no PLAIN
 		xt.Assert(t, !xstrings.SliceHas(ses.ServerCapabilities().AuthMechanisms, string(xmysql.AuthMethodPlain)))
 		xt.Assert(t, !ses.ServerCapabilities().TLS)
+		xt.Eq(t, "mysql", ses.ServerCapabilities().NodeType)
+		xt.Eq(t, "text", ses.ServerCapabilities().DocFormats)
+		xt.Eq(t, "mysql", ses.ServerCapabilities().All["node_type"])
+	})
+
+	t.Run("set capabilities before authenticating", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Username: "user_native",
+			Address:  testContext.XPluginAddr,
+			Capabilities: map[string]any{
+				"client.interactive":   true,
+				"client.pwd_expire_ok": true,
+			},
+		}
+		config.SetPassword("pwd_user_native")
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+		defer func() { _ = ses.Close() }()
+	})
+
+	t.Run("capabilities set by the session cannot be set", func(t *testing.T) {
+		_, err := xmysql.NewSession(&xmysql.ConnectConfig{
+			Capabilities: map[string]any{"tls": true},
+		})
+		xt.KO(t, err)
+		xt.Eq(t, "capability tls is set using its own configuration", err.Error())
+	})
+
+	t.Run("capability with unsupported value type", func(t *testing.T) {
+		_, err := xmysql.NewSession(&xmysql.ConnectConfig{
+			Capabilities: map[string]any{"client.interactive": float32(1)},
+		})
+		xt.KO(t, err)
+		xt.Eq(t, "capability client.interactive (unsupported capability value type float32)", err.Error())
 	})
 
 	t.Run("incorrectly connect to conventional MySQL Protocol", func(t *testing.T) {
@@ -271,6 +341,159 @@ func TestCreateSession(t *testing.T) {
 		xt.Assert(t, ses.ServerCapabilities().TLS)
 	})
 
+	t.Run("SSL modes", func(t *testing.T) {
+		var cases = map[xmysql.SSLMode]bool{
+			xmysql.SSLModeDisabled:  false,
+			xmysql.SSLModePreferred: true,
+			xmysql.SSLModeRequired:  true,
+		}
+
+		for mode, usesTLS := range cases {
+			t.Run(string(mode), func(t *testing.T) {
+				config := &xmysql.ConnectConfig{
+					Address:  testContext.XPluginAddr,
+					SSLMode:  mode,
+					Username: xxt.UserNative,
+				}
+				config.SetPassword(xxt.UserNativePwd)
+
+				ses, err := xmysql.GetSession(context.Background(), config)
+				xt.OK(t, err)
+				xt.Eq(t, usesTLS, ses.UsesTLS())
+				xt.Assert(t, ses.ServerCapabilities().TLSSupported)
+			})
+		}
+	})
+
+	t.Run("SSL mode verifying server certificate using system CAs", func(t *testing.T) {
+		for _, mode := range []xmysql.SSLMode{xmysql.SSLModeVerifyCA, xmysql.SSLModeVerifyIdentity} {
+			t.Run(string(mode), func(t *testing.T) {
+				config := &xmysql.ConnectConfig{
+					Address:  testContext.XPluginAddr,
+					SSLMode:  mode,
+					Username: xxt.UserNative,
+				}
+				config.SetPassword(xxt.UserNativePwd)
+
+				// the MySQL server uses a self-signed CA
+				_, err := xmysql.GetSession(context.Background(), config)
+				xt.KO(t, err)
+				xt.Assert(t, strings.Contains(err.Error(), "failed handshake"), err.Error())
+			})
+		}
+	})
+
+	t.Run("unsupported SSL mode", func(t *testing.T) {
+		_, err := xmysql.NewSession(&xmysql.ConnectConfig{SSLMode: "SOMETIMES"})
+		xt.KO(t, err)
+		xt.Eq(t, "unsupported SSL mode 'SOMETIMES'", err.Error())
+	})
+
+	t.Run("minimum TLS version higher than maximum", func(t *testing.T) {
+		_, err := xmysql.NewSession(&xmysql.ConnectConfig{
+			TLSMinVersion: tls.VersionTLS13,
+			TLSMaxVersion: tls.VersionTLS12,
+		})
+		xt.KO(t, err)
+		xt.Eq(t, "minimum TLS version is higher than maximum", err.Error())
+	})
+
+	t.Run("TLS versions and cipher suites", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Address:         testContext.XPluginAddr,
+			SSLMode:         xmysql.SSLModeRequired,
+			Username:        xxt.UserNative,
+			TLSMinVersion:   tls.VersionTLS12,
+			TLSMaxVersion:   tls.VersionTLS12,
+			TLSCipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+		xt.Assert(t, ses.UsesTLS(), "expected tls.Conn")
+	})
+
+	t.Run("TLS client certificate without key", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Address:           testContext.XPluginAddr,
+			SSLMode:           xmysql.SSLModeRequired,
+			Username:          xxt.UserNative,
+			TLSClientCertPath: "_testdata/mysql_ca.pem",
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		_, err := xmysql.GetSession(context.Background(), config)
+		xt.KO(t, err)
+		xt.Assert(t, strings.Contains(err.Error(), "TLS client certificate and key must both be configured"),
+			err.Error())
+	})
+
+	t.Run("function providing TLS configuration takes precedence", func(t *testing.T) {
+		var calls int
+		provided := &tls.Config{InsecureSkipVerify: true}
+
+		config := &xmysql.ConnectConfig{
+			Address:  testContext.XPluginAddr,
+			Username: xxt.UserNative,
+			// would fail the handshake when used
+			TLSConfig: &tls.Config{MinVersion: tls.VersionTLS13, MaxVersion: tls.VersionTLS12},
+			GetTLSConfig: func(ctx context.Context) (*tls.Config, error) {
+				calls++
+				return provided, nil
+			},
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+		xt.Assert(t, ses.UsesTLS(), "expected tls.Conn")
+		xt.Eq(t, 1, calls)
+		xt.Eq(t, "", provided.ServerName, "provided configuration must not be modified")
+	})
+
+	t.Run("certificate revocation list not signed by server's CA", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		xt.OK(t, err)
+
+		caTmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "pxmysql test CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &key.PublicKey, key)
+		xt.OK(t, err)
+		ca, err := x509.ParseCertificate(caDER)
+		xt.OK(t, err)
+
+		crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: time.Now().Add(-time.Minute),
+			NextUpdate: time.Now().Add(time.Hour),
+		}, ca, key)
+		xt.OK(t, err)
+
+		crlPath := filepath.Join(t.TempDir(), "crl.pem")
+		xt.OK(t, os.WriteFile(crlPath, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crlDER}), 0600))
+
+		config := &xmysql.ConnectConfig{
+			Address:             testContext.XPluginAddr,
+			SSLMode:             xmysql.SSLModeVerifyCA,
+			Username:            xxt.UserNative,
+			TLSServerCACertPath: "_testdata/mysql_ca.pem",
+			TLSCRLPath:          crlPath,
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		_, err = xmysql.GetSession(context.Background(), config)
+		xt.KO(t, err)
+		xt.Assert(t, strings.Contains(err.Error(), "verifying certificate revocation list"), err.Error())
+	})
+
 	t.Run("cannot use PLAIN authn method without TLS", func(t *testing.T) {
 		config := &xmysql.ConnectConfig{
 			Address:    testContext.XPluginAddr,
@@ -349,6 +572,24 @@ func TestCreateSession(t *testing.T) {
 		xt.Assert(t, ses.ServerCapabilities().TLS)
 	})
 
+	t.Run("AUTO authn method negotiates with server", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Address:    testContext.XPluginAddr,
+			UseTLS:     false,
+			AuthMethod: xmysql.AuthMethodAuto,
+			Username:   xxt.UserNative,
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+		defer func() { xt.OK(t, ses.Close()) }()
+
+		// PLAIN is not reported by the server when not using TLS
+		xt.Assert(t, !xstrings.SliceHas(ses.ServerCapabilities().AuthMechanisms, string(xmysql.AuthMethodPlain)))
+		xt.Eq(t, xmysql.AuthMethodMySQL41, ses.AuthMethod())
+	})
+
 	t.Run("SHA256 caching after plain authentication using TLS", func(t *testing.T) {
 		xt.OK(t, testContext.Server.FlushPrivileges())
 		password := xxt.UserCachedSHA256Pwd
@@ -420,6 +661,290 @@ func TestCreateSession(t *testing.T) {
 
 		xt.Eq(t, exp, sesZone)
 	})
+
+	t.Run("session uses configured collation", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Address:   testContext.XPluginAddr,
+			Username:  xxt.UserNative,
+			Collation: "utf8mb4_bin",
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+
+		c, err := ses.Collation(context.Background())
+		xt.OK(t, err)
+		xt.Eq(t, "utf8mb4_bin", c.Name)
+	})
+
+	t.Run("configured maximum allowed packet", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Address:          testContext.XPluginAddr,
+			Username:         xxt.UserNative,
+			MaxAllowedPacket: 1024,
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+
+		_, err = ses.ExecuteStatement(context.Background(), "SELECT ?", strings.Repeat("a", 2048))
+		xt.KO(t, err)
+		var errMySQL *mysqlerrors.Error
+		xt.Assert(t, errors.As(err, &errMySQL), fmt.Sprintf("got: %s", err))
+		xt.Eq(t, mysqlerrors.ClientNetPacketTooLarge, errMySQL.Code)
+	})
+
+	t.Run("connection attributes", func(t *testing.T) {
+		getAttrs := func(t *testing.T, config *xmysql.ConnectConfig) map[string]string {
+			t.Helper()
+
+			config.Address = testContext.XPluginAddr
+			config.Username = xxt.UserNative
+			config.SetPassword(xxt.UserNativePwd)
+
+			ses, err := xmysql.GetSession(context.Background(), config)
+			xt.OK(t, err)
+			defer func() { _ = ses.Close() }()
+
+			res, err := ses.ExecuteStatement(context.Background(),
+				"SELECT ATTR_NAME, ATTR_VALUE FROM performance_schema.session_connect_attrs "+
+					"WHERE PROCESSLIST_ID = CONNECTION_ID()")
+			xt.OK(t, err)
+
+			attrs := map[string]string{}
+			for _, row := range res.Rows {
+				attrs[row.Values[0].(string)] = row.Values[1].(string)
+			}
+			return attrs
+		}
+
+		t.Run("defaults and custom", func(t *testing.T) {
+			attrs := getAttrs(t, &xmysql.ConnectConfig{
+				ConnectionAttributes: map[string]string{
+					"program_name": "pxmysql-test",
+					"team":         "gophers",
+				},
+			})
+
+			xt.Eq(t, xmysql.ClientName, attrs["_client_name"])
+			xt.Eq(t, strconv.Itoa(os.Getpid()), attrs["_pid"])
+			xt.Eq(t, "pxmysql-test", attrs["program_name"])
+			xt.Eq(t, "gophers", attrs["team"])
+		})
+
+		t.Run("disabled", func(t *testing.T) {
+			attrs := getAttrs(t, &xmysql.ConnectConfig{
+				DisableConnectionAttributes: true,
+			})
+			xt.Eq(t, 0, len(attrs))
+		})
+	})
+
+	t.Run("compression", func(t *testing.T) {
+		for _, mode := range []xmysql.CompressionMode{xmysql.CompressionPreferred, xmysql.CompressionRequired} {
+			t.Run(string(mode), func(t *testing.T) {
+				config := &xmysql.ConnectConfig{
+					Address:     testContext.XPluginAddr,
+					Username:    xxt.UserNative,
+					Compression: mode,
+				}
+				config.SetPassword(xxt.UserNativePwd)
+
+				ses, err := xmysql.GetSession(context.Background(), config)
+				xt.OK(t, err)
+				defer func() { _ = ses.Close() }()
+
+				xt.Eq(t, xmysql.CompressionDeflateStream, ses.CompressionAlgorithm())
+				xt.Assert(t, slices.Contains(ses.ServerCapabilities().CompressionAlgorithms,
+					xmysql.CompressionDeflateStream))
+
+				long := strings.Repeat("gopher", 10000)
+				for i := 0; i < 3; i++ {
+					res, err := ses.ExecuteStatement(context.Background(), "SELECT ?, REPEAT('x', 20000)", long)
+					xt.OK(t, err)
+					xt.Eq(t, long, res.Rows[0].Values[0].(string))
+					xt.Eq(t, strings.Repeat("x", 20000), res.Rows[0].Values[1].(string))
+				}
+			})
+		}
+
+		t.Run("disabled", func(t *testing.T) {
+			config := &xmysql.ConnectConfig{
+				Address:  testContext.XPluginAddr,
+				Username: xxt.UserNative,
+			}
+			config.SetPassword(xxt.UserNativePwd)
+
+			ses, err := xmysql.GetSession(context.Background(), config)
+			xt.OK(t, err)
+			defer func() { _ = ses.Close() }()
+
+			xt.Eq(t, "", ses.CompressionAlgorithm())
+		})
+
+		t.Run("no supported algorithm", func(t *testing.T) {
+			config := &xmysql.ConnectConfig{
+				Address:               testContext.XPluginAddr,
+				Username:              xxt.UserNative,
+				Compression:           xmysql.CompressionRequired,
+				CompressionAlgorithms: []string{xmysql.CompressionZstdStream},
+			}
+			config.SetPassword(xxt.UserNativePwd)
+
+			_, err := xmysql.GetSession(context.Background(), config)
+			xt.KO(t, err)
+			xt.Eq(t, "no compression algorithm supported by both client and server", err.Error())
+		})
+	})
+}
+
+func TestNewServerCapabilitiesFromMessage(t *testing.T) {
+	capability := func(name string, value *mysqlxdatatypes.Any) *mysqlxconnection.Capability {
+		return &mysqlxconnection.Capability{Name: proto.String(name), Value: value}
+	}
+
+	strs := func(values ...string) *mysqlxdatatypes.Any {
+		a := &mysqlxdatatypes.Any{
+			Type:  mysqlxdatatypes.Any_ARRAY.Enum(),
+			Array: &mysqlxdatatypes.Array{},
+		}
+		for _, v := range values {
+			a.Array.Value = append(a.Array.Value, xproto.String(v))
+		}
+		return a
+	}
+
+	b, err := proto.Marshal(&mysqlxconnection.Capabilities{
+		Capabilities: []*mysqlxconnection.Capability{
+			capability("tls", xproto.Bool(true)),
+			capability("authentication.mechanisms", strs("MYSQL41", "SHA256_MEMORY")),
+			capability("doc.formats", xproto.String("text")),
+			capability("node_type", xproto.String("mysql")),
+			capability("plugin.version", xproto.String("2.0")),
+			capability("client.pwd_expire_ok", xproto.Bool(true)),
+			capability("client.interactive", xproto.Bool(false)),
+			capability("session_connect_attrs", xproto.Bool(true)),
+			capability("compression", xproto.Object(
+				&mysqlxdatatypes.Object_ObjectField{
+					Key:   proto.String("algorithm"),
+					Value: strs("deflate_stream", "lz4_message"),
+				},
+				xproto.ObjectField("server_combine_mixed_messages", true),
+			)),
+			capability("some.number", xproto.UnsignedInt(uint64(42))),
+		},
+	})
+	xt.OK(t, err)
+
+	frame := make([]byte, 5, 5+len(b))
+	binary.LittleEndian.PutUint32(frame, uint32(len(b))+1)
+	frame[4] = byte(mysqlx.ServerMessages_CONN_CAPABILITIES)
+	frame = append(frame, b...)
+
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+	go func() {
+		_, _ = server.Write(frame)
+	}()
+
+	msg, err := network.Read(context.Background(), client)
+	xt.OK(t, err)
+
+	sc, err := xmysql.NewServerCapabilitiesFromMessage(msg)
+	xt.OK(t, err)
+
+	xt.Assert(t, sc.TLSSupported)
+	xt.Assert(t, sc.TLS)
+	xt.Eq(t, []string{"MYSQL41", "SHA256_MEMORY"}, sc.AuthMechanisms)
+	xt.Eq(t, []string{"deflate_stream", "lz4_message"}, sc.CompressionAlgorithms)
+	xt.Eq(t, "text", sc.DocFormats)
+	xt.Eq(t, "mysql", sc.NodeType)
+	xt.Eq(t, "2.0", sc.PluginVersion)
+	xt.Assert(t, sc.ClientPwdExpireOK)
+	xt.Assert(t, !sc.ClientInteractive)
+	xt.Assert(t, sc.SessionConnectAttrs)
+
+	xt.Eq(t, 10, len(sc.All))
+	xt.Eq(t, "mysql", sc.All["node_type"])
+	xt.Eq(t, uint64(42), sc.All["some.number"])
+	xt.Eq(t, []any{"MYSQL41", "SHA256_MEMORY"}, sc.All["authentication.mechanisms"])
+	xt.Eq(t, map[string]any{
+		"algorithm":                     []any{"deflate_stream", "lz4_message"},
+		"server_combine_mixed_messages": true,
+	}, sc.All["compression"])
+}
+
+type testAuthenticator struct {
+	name xmysql.AuthMethodType
+}
+
+func (a *testAuthenticator) Name() xmysql.AuthMethodType {
+	return a.name
+}
+
+func (a *testAuthenticator) Start(creds xmysql.AuthCredentials) ([]byte, error) {
+	return []byte(creds.Username), nil
+}
+
+func (a *testAuthenticator) Continue(_ xmysql.AuthCredentials, challenge []byte) ([]byte, error) {
+	return challenge, nil
+}
+
+func TestRegisterAuthenticator(t *testing.T) {
+	t.Run("built-in mechanisms", func(t *testing.T) {
+		for _, name := range []xmysql.AuthMethodType{
+			xmysql.AuthMethodMySQL41, xmysql.AuthMethodPlain, xmysql.AuthMethodSHA256Memory, xmysql.AuthMethodAuto,
+		} {
+			xt.Assert(t, xmysql.SupportedAuthMethods().Has(name), string(name))
+		}
+	})
+
+	t.Run("register", func(t *testing.T) {
+		_, err := xmysql.NewSession(&xmysql.ConnectConfig{AuthMethod: "TEST_REGISTER"})
+		xt.KO(t, err)
+		xt.Eq(t, "unsupported authentication type 'TEST_REGISTER'", err.Error())
+
+		xt.OK(t, xmysql.RegisterAuthenticator(func() xmysql.Authenticator {
+			return &testAuthenticator{name: "TEST_REGISTER"}
+		}))
+		xt.Assert(t, xmysql.SupportedAuthMethods().Has("TEST_REGISTER"))
+
+		_, err = xmysql.NewSession(&xmysql.ConnectConfig{AuthMethod: "TEST_REGISTER"})
+		xt.OK(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var cases = map[string]struct {
+			newFunc func() xmysql.Authenticator
+			exp     string
+		}{
+			"nil function": {
+				exp: "authenticator cannot be nil",
+			},
+			"nil authenticator": {
+				newFunc: func() xmysql.Authenticator { return nil },
+				exp:     "authenticator cannot be nil",
+			},
+			"empty name": {
+				newFunc: func() xmysql.Authenticator { return &testAuthenticator{} },
+				exp:     "authenticator name cannot be empty",
+			},
+			"AUTO": {
+				newFunc: func() xmysql.Authenticator { return &testAuthenticator{name: xmysql.AuthMethodAuto} },
+				exp:     "authenticator name AUTO is reserved",
+			},
+		}
+
+		for name, c := range cases {
+			t.Run(name, func(t *testing.T) {
+				err := xmysql.RegisterAuthenticator(c.newFunc)
+				xt.KO(t, err)
+				xt.Eq(t, c.exp, err.Error())
+			})
+		}
+	})
 }
 
 func TestSession_ExecuteStatement(t *testing.T) {
@@ -628,7 +1153,7 @@ func TestSession_ExecuteStatement(t *testing.T) {
 			id := row.Values[0].(int64)
 
 			t.Run(fmt.Sprintf("row=%d", id), func(t *testing.T) {
-				xt.Assert(t, xmysql.IsSupportedCollation(res.Columns[1].GetCollation()))
+				xt.Assert(t, xmysql.IsSupportedCollation(res.Columns[1].Collation))
 				xt.Eq(t, exp[id].sChar, row.Values[1].(string))
 				xt.Eq(t, exp[id].sVarchar, row.Values[2].(string))
 				xt.Eq(t, exp[id].sBinary, row.Values[3].([]byte))
@@ -678,6 +1203,94 @@ func TestSession_ExecuteStatement(t *testing.T) {
 		xt.Eq(t, 3, res.Rows[0].Values[3].(int64))
 	})
 
+	t.Run("times are substituted using the session's location", func(t *testing.T) {
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+		xt.Eq(t, time.UTC, ses.TimeLocation())
+
+		tm := time.Date(2023, 9, 1, 13, 4, 5, 0, time.FixedZone("UTC+5", 5*60*60))
+
+		res, err := ses.ExecuteStatement(context.Background(), "SELECT ?, ?, ?, ?",
+			tm, &tm, null.Time{Time: tm, Valid: true}, null.New(tm))
+		xt.OK(t, err)
+
+		xt.Assert(t, len(res.Rows) == 1, "expected 1 row")
+		for i := 0; i < 4; i++ {
+			xt.Eq(t, "2023-09-01 08:04:05", res.Rows[0].Values[i].(string))
+		}
+	})
+
+	t.Run("use named placeholders", func(t *testing.T) {
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+
+		t.Run("map", func(t *testing.T) {
+			res, err := ses.ExecuteStatement(context.Background(),
+				"SELECT :id, @name, ':id', :id, @unknown from dual", map[string]any{"id": 1, "name": "one"})
+			xt.OK(t, err)
+
+			xt.Assert(t, len(res.Rows) == 1, "expected 1 row")
+			xt.Eq(t, 1, res.Rows[0].Values[0].(int64))
+			xt.Eq(t, "one", res.Rows[0].Values[1].(string))
+			xt.Eq(t, ":id", res.Rows[0].Values[2].(string))
+			xt.Eq(t, 1, res.Rows[0].Values[3].(int64))
+		})
+
+		t.Run("struct", func(t *testing.T) {
+			type args struct {
+				ID     int
+				Name   string `db:"full_name"`
+				Unused bool
+			}
+
+			res, err := ses.ExecuteStatement(context.Background(),
+				"SELECT :id, :full_name from dual", args{ID: 2, Name: "two"})
+			xt.OK(t, err)
+
+			xt.Assert(t, len(res.Rows) == 1, "expected 1 row")
+			xt.Eq(t, 2, res.Rows[0].Values[0].(int64))
+			xt.Eq(t, "two", res.Rows[0].Values[1].(string))
+		})
+
+		t.Run("missing argument", func(t *testing.T) {
+			_, err := ses.ExecuteStatement(context.Background(),
+				"SELECT :id, :name from dual", map[string]any{"id": 1})
+			xt.KO(t, err)
+			xt.Eq(t, "missing argument for named placeholder 'name'", err.Error())
+		})
+
+		t.Run("argument not used", func(t *testing.T) {
+			_, err := ses.ExecuteStatement(context.Background(),
+				"SELECT :id from dual", map[string]any{"id": 1, "name": "one"})
+			xt.KO(t, err)
+			xt.Eq(t, "named argument 'name' not used", err.Error())
+		})
+	})
+
+	t.Run("expand slices", func(t *testing.T) {
+		cfg := config.Clone()
+		cfg.SetPassword(xxt.UserNativePwd)
+		cfg.ExpandSlices = true
+
+		ses, err := xmysql.GetSession(context.Background(), cfg)
+		xt.OK(t, err)
+
+		res, err := ses.ExecuteStatement(context.Background(),
+			"SELECT 2 IN (?), 'b' IN (?)", []int{1, 2}, []string{"a", "c"})
+		xt.OK(t, err)
+		xt.Eq(t, 1, res.Rows[0].Values[0].(int64))
+		xt.Eq(t, 0, res.Rows[0].Values[1].(int64))
+
+		res, err = ses.ExecuteStatement(context.Background(),
+			"SELECT :n IN (:ids)", map[string]any{"n": 3, "ids": []uint{3, 4}})
+		xt.OK(t, err)
+		xt.Eq(t, 1, res.Rows[0].Values[0].(int64))
+
+		_, err = ses.ExecuteStatement(context.Background(), "SELECT 1 IN (?)", []int{})
+		xt.KO(t, err)
+		xt.Eq(t, "cannot expand empty slice for placeholder 1", err.Error())
+	})
+
 	t.Run("zero hour timestamp", func(t *testing.T) {
 		ses, err := xmysql.GetSession(context.Background(), config)
 		xt.OK(t, err)
@@ -690,6 +1303,39 @@ func TestSession_ExecuteStatement(t *testing.T) {
 		have := res.Rows[0].Values[0].(null.Time)
 		have.Time.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
 	})
+
+	t.Run("generic nullable types", func(t *testing.T) {
+		cfg := config.Clone()
+		cfg.SetPassword(xxt.UserNativePwd)
+		cfg.UseNullOf = true
+
+		ses, err := xmysql.GetSession(context.Background(), cfg)
+		xt.OK(t, err)
+
+		res, err := ses.ExecuteStatement(context.Background(),
+			"SELECT CAST(NULL AS CHAR), TIMESTAMP('2023-01-01 01:00:00')")
+		xt.OK(t, err)
+		xt.Eq(t, 1, len(res.Rows))
+
+		xt.Assert(t, !res.Rows[0].Values[0].(null.Of[string]).Valid)
+		have := res.Rows[0].Values[1].(null.Of[time.Time])
+		xt.Assert(t, have.Valid)
+		xt.Assert(t, have.V.Equal(time.Date(2023, 1, 1, 1, 0, 0, 0, time.UTC)))
+
+		// session information is read from nullable columns as well
+		_, err = ses.Collation(context.Background())
+		xt.OK(t, err)
+		_, err = ses.TimeZone(context.Background())
+		xt.OK(t, err)
+		schemas, err := ses.Schemas(context.Background())
+		xt.OK(t, err)
+		xt.Assert(t, len(schemas) > 0)
+
+		schema, err := ses.GetSchemaWithName(context.Background(), testSchema)
+		xt.OK(t, err)
+		_, err = schema.GetCollections(context.Background())
+		xt.OK(t, err)
+	})
 }
 
 func TestSession_CurrentSchema(t *testing.T) {
@@ -849,6 +1495,36 @@ func TestSession_DeallocatePrepareStatement(t *testing.T) {
 	})
 }
 
+func TestSession_Reset(t *testing.T) {
+	config := &xmysql.ConnectConfig{
+		Address:  testContext.XPluginAddr,
+		Username: xxt.UserNative,
+	}
+	config.SetPassword(xxt.UserNativePwd)
+
+	ses, err := xmysql.GetSession(context.Background(), config)
+	xt.OK(t, err)
+
+	t.Run("user variables and prepared statements are gone", func(t *testing.T) {
+		_, err := ses.ExecuteStatement(context.Background(), "SET @pxmysql_reset = 1")
+		xt.OK(t, err)
+
+		prep, err := ses.PrepareStatement(context.Background(), "SELECT ?")
+		xt.OK(t, err)
+
+		epoch := ses.Epoch()
+		xt.OK(t, ses.Reset(context.Background()))
+		xt.Eq(t, epoch+1, ses.Epoch())
+
+		res, err := ses.ExecuteStatement(context.Background(), "SELECT @pxmysql_reset")
+		xt.OK(t, err)
+		xt.Eq(t, nil, res.Rows[0].Values[0])
+
+		_, err = prep.Execute(context.Background(), 3)
+		xxt.AssertMySQLError(t, err, 5110)
+	})
+}
+
 func TestSession_ActiveSchemaName(t *testing.T) {
 	config := &xmysql.ConnectConfig{
 		Address:  testContext.XPluginAddr,
@@ -947,3 +1623,300 @@ func TestSession_CreateSchema(t *testing.T) {
 		xt.Eq(t, exp, errors.Unwrap(err).Error())
 	})
 }
+
+func TestSession_ChangePassword(t *testing.T) {
+	username := "pxmysql_expired"
+	password := "pwd_expired"
+	newPassword := "pwd_not_expired"
+
+	_ = testContext.Server.DropUser(username)
+	xt.OK(t, testContext.Server.CreateUser(username, password, testSchema, xxt.AuthPluginNative))
+	defer func() { _ = testContext.Server.DropUser(username) }()
+
+	_, err := testContext.Server.ExecSQLStmt(fmt.Sprintf("ALTER USER '%s'@'%%' PASSWORD EXPIRE", username))
+	xt.OK(t, err)
+
+	config := &xmysql.ConnectConfig{
+		Address:  testContext.XPluginAddr,
+		Username: username,
+	}
+
+	t.Run("expired password is not allowed", func(t *testing.T) {
+		_, err := xmysql.GetSession(context.Background(), config.Clone().SetPassword(password))
+		xt.KO(t, err)
+		xt.Assert(t, errors.Is(err, xmysql.ErrPasswordExpired), fmt.Sprintf("got: %s", err))
+
+		var myErr *mysqlerrors.Error
+		xt.Assert(t, errors.As(err, &myErr), fmt.Sprintf("got: %s", err))
+		// ER_MUST_CHANGE_PASSWORD_LOGIN or ER_MUST_CHANGE_PASSWORD
+		xt.Assert(t, myErr.Code == 1862 || myErr.Code == 1820, fmt.Sprintf("got: %d", myErr.Code))
+	})
+
+	t.Run("other errors are not reported as expired password", func(t *testing.T) {
+		_, err := xmysql.GetSession(context.Background(), config.Clone().SetPassword("wrong"))
+		xt.KO(t, err)
+		xt.Assert(t, !errors.Is(err, xmysql.ErrPasswordExpired), fmt.Sprintf("got: %s", err))
+
+		var myErr *mysqlerrors.Error
+		xt.Assert(t, errors.As(err, &myErr), fmt.Sprintf("got: %s", err))
+		xt.Eq(t, 1045, myErr.Code) // ER_ACCESS_DENIED_ERROR
+	})
+
+	t.Run("allow expired password and change it", func(t *testing.T) {
+		cfg := config.Clone().SetPassword(password)
+		cfg.AllowExpiredPassword = true
+
+		ses, err := xmysql.GetSession(context.Background(), cfg)
+		xt.OK(t, err)
+		defer func() { _ = ses.Close() }()
+		xt.Assert(t, ses.PasswordExpired())
+
+		_, err = ses.ExecuteStatement(context.Background(), "SELECT 1")
+		xt.KO(t, err)
+		xt.Assert(t, errors.Is(err, xmysql.ErrPasswordExpired), fmt.Sprintf("got: %s", err))
+		var myErr *mysqlerrors.Error
+		xt.Assert(t, errors.As(err, &myErr), fmt.Sprintf("got: %s", err))
+		xt.Eq(t, 1820, myErr.Code) // ER_MUST_CHANGE_PASSWORD
+
+		xt.OK(t, ses.ChangePassword(context.Background(), newPassword))
+		xt.Assert(t, !ses.PasswordExpired())
+
+		_, err = ses.ExecuteStatement(context.Background(), "SELECT 1")
+		xt.OK(t, err)
+
+		again, err := xmysql.GetSession(context.Background(), config.Clone().SetPassword(newPassword))
+		xt.OK(t, err)
+		xt.Assert(t, !again.PasswordExpired())
+		_ = again.Close()
+	})
+}
+
+func TestSession_CredentialsProvider(t *testing.T) {
+	username := "pxmysql_rotated"
+	password := "pwd_rotated_1"
+	rotatedPassword := "pwd_rotated_2"
+
+	_ = testContext.Server.DropUser(username)
+	xt.OK(t, testContext.Server.CreateUser(username, password, testSchema, xxt.AuthPluginNative))
+	defer func() { _ = testContext.Server.DropUser(username) }()
+
+	t.Run("called on every open", func(t *testing.T) {
+		current := password
+		var calls int
+
+		config := &xmysql.ConnectConfig{
+			Address:  testContext.XPluginAddr,
+			Username: "not_used",
+			CredentialsProvider: func(ctx context.Context) (string, string, error) {
+				calls++
+				return username, current, nil
+			},
+		}
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+		xt.Eq(t, 1, calls)
+		xt.OK(t, ses.Close())
+
+		_, err = testContext.Server.ExecSQLStmt(
+			fmt.Sprintf("ALTER USER '%s'@'%%' IDENTIFIED BY '%s'", username, rotatedPassword))
+		xt.OK(t, err)
+		current = rotatedPassword
+
+		xt.OK(t, ses.Open(context.Background()))
+		defer func() { _ = ses.Close() }()
+		xt.Eq(t, 2, calls)
+
+		res, err := ses.ExecuteStatement(context.Background(), "SELECT SUBSTRING_INDEX(CURRENT_USER(), '@', 1)")
+		xt.OK(t, err)
+		xt.Eq(t, username, res.Rows[0].Values[0])
+	})
+
+	t.Run("empty user uses configured username", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Address:  testContext.XPluginAddr,
+			Username: xxt.UserNative,
+			CredentialsProvider: func(ctx context.Context) (string, string, error) {
+				return "", xxt.UserNativePwd, nil
+			},
+		}
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+		xt.OK(t, ses.Close())
+	})
+
+	t.Run("provider fails", func(t *testing.T) {
+		errProvider := errors.New("token expired")
+
+		config := &xmysql.ConnectConfig{
+			Address: testContext.XPluginAddr,
+			CredentialsProvider: func(ctx context.Context) (string, string, error) {
+				return "", "", errProvider
+			},
+		}
+
+		_, err := xmysql.GetSession(context.Background(), config)
+		xt.KO(t, err)
+		xt.Assert(t, errors.Is(err, errProvider))
+		xt.Eq(t, "getting credentials (token expired)", err.Error())
+	})
+}
+
+func TestSession_Hosts(t *testing.T) {
+	t.Run("fails over to next host", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Hosts: []xmysql.Host{
+				{Address: testContext.XPluginAddr, Priority: 50},
+				{Address: "127.0.0.1:1", Priority: 100},
+			},
+			Username:       xxt.UserNative,
+			ConnectTimeout: 500 * time.Millisecond,
+		}
+		config.SetPassword(xxt.UserNativePwd)
+
+		ses, err := xmysql.GetSession(context.Background(), config)
+		xt.OK(t, err)
+		defer func() { _ = ses.Close() }()
+
+		xt.Eq(t, testContext.XPluginAddr, ses.Address())
+	})
+
+	t.Run("reports each host which failed", func(t *testing.T) {
+		config := &xmysql.ConnectConfig{
+			Hosts: []xmysql.Host{
+				{Address: "127.0.0.1:1"},
+				{Address: "127.0.0.1:2"},
+			},
+			Username: xxt.UserNative,
+		}
+
+		_, err := xmysql.GetSession(context.Background(), config)
+		xt.KO(t, err)
+		xt.Assert(t, strings.HasPrefix(err.Error(), "failed connecting to any host ("), err.Error())
+		xt.Assert(t, strings.Contains(err.Error(), "127.0.0.1:1 ("), err.Error())
+		xt.Assert(t, strings.Contains(err.Error(), "127.0.0.1:2 ("), err.Error())
+	})
+
+	// tried returns the addresses of hosts in the order they were tried
+	// according to the error opening the session
+	tried := func(t *testing.T, err error, hosts []xmysql.Host) []string {
+		t.Helper()
+
+		xt.KO(t, err)
+		addresses := make([]string, len(hosts))
+		for i, h := range hosts {
+			addresses[i] = h.Address
+		}
+		sort.Slice(addresses, func(i, j int) bool {
+			return strings.Index(err.Error(), addresses[i]+" (") < strings.Index(err.Error(), addresses[j]+" (")
+		})
+
+		return addresses
+	}
+
+	// all hosts refuse connections
+	hosts := []xmysql.Host{
+		{Address: "127.0.0.1:1"},
+		{Address: "127.0.0.1:2", Priority: 100},
+		{Address: "127.0.0.1:3", Priority: 50},
+		{Address: "127.0.0.1:4", Priority: 100},
+		{Address: "127.0.0.1:5", Priority: 50},
+		{Address: "127.0.0.1:6", Priority: 50},
+	}
+
+	t.Run("tried by priority in given order", func(t *testing.T) {
+		ses, err := xmysql.NewSession(&xmysql.ConnectConfig{Hosts: hosts})
+		xt.OK(t, err)
+
+		xt.Eq(t, []string{"127.0.0.1:2", "127.0.0.1:4", "127.0.0.1:3", "127.0.0.1:5", "127.0.0.1:6", "127.0.0.1:1"},
+			tried(t, ses.Open(context.Background()), hosts))
+		xt.Eq(t, "", ses.Address())
+	})
+
+	t.Run("tried by priority in random order", func(t *testing.T) {
+		ses, err := xmysql.NewSession(&xmysql.ConnectConfig{Hosts: hosts, RandomizeHosts: true})
+		xt.OK(t, err)
+
+		priorities := map[string]int{}
+		for _, h := range hosts {
+			priorities[h.Address] = h.Priority
+		}
+
+		for i := 0; i < 10; i++ {
+			var have []int
+			for _, addr := range tried(t, ses.Open(context.Background()), hosts) {
+				have = append(have, priorities[addr])
+			}
+			xt.Eq(t, []int{100, 100, 50, 50, 50, 0}, have)
+		}
+	})
+
+	t.Run("addresses get defaults and configuration is not changed", func(t *testing.T) {
+		hosts := []xmysql.Host{{Address: ""}, {Address: "db.example.com"}, {Address: ":33070"}, {Address: "[::1]:33070"}}
+
+		ses, err := xmysql.NewSession(&xmysql.ConnectConfig{Hosts: hosts})
+		xt.OK(t, err)
+
+		xt.Eq(t, []xmysql.Host{
+			{Address: xmysql.DefaultHost + ":" + xmysql.DefaultPort},
+			{Address: "db.example.com:" + xmysql.DefaultPort},
+			{Address: xmysql.DefaultHost + ":33070"},
+			{Address: "[::1]:33070"},
+		}, ses.Config().Hosts)
+		xt.Eq(t, "db.example.com", hosts[1].Address)
+	})
+
+	t.Run("priority out of range", func(t *testing.T) {
+		_, err := xmysql.NewSession(&xmysql.ConnectConfig{Hosts: []xmysql.Host{{Address: "db1", Priority: 101}}})
+		xt.KO(t, err)
+		xt.Eq(t, "host priority must be between 0 and 100 (was 101)", err.Error())
+	})
+
+	t.Run("connect timeout limits each attempt", func(t *testing.T) {
+		// silentHost accepts connections but never sends the hello of the server
+		silentHost := func(t *testing.T) string {
+			t.Helper()
+
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			xt.OK(t, err)
+
+			done := make(chan struct{})
+			t.Cleanup(func() {
+				_ = l.Close()
+				<-done
+			})
+
+			go func() {
+				defer close(done)
+				var conns []net.Conn
+				for {
+					c, err := l.Accept()
+					if err != nil {
+						break
+					}
+					conns = append(conns, c)
+				}
+				for _, c := range conns {
+					_ = c.Close()
+				}
+			}()
+
+			return l.Addr().String()
+		}
+
+		ses, err := xmysql.NewSession(&xmysql.ConnectConfig{
+			Hosts:          []xmysql.Host{{Address: silentHost(t)}, {Address: silentHost(t)}},
+			ConnectTimeout: 100 * time.Millisecond,
+		})
+		xt.OK(t, err)
+
+		start := time.Now()
+		err = ses.Open(context.Background())
+		xt.KO(t, err)
+		xt.Assert(t, time.Since(start) < 2*time.Second, time.Since(start).String())
+
+		xt.Assert(t, ses.ServerCapabilities() == nil)
+		xt.Eq(t, "", ses.Address())
+	})
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golistic/pxmysql/xmysql/internal/network"
+)
+
+// SSLMode defines whether and how TLS is used for the connection. The modes
+// follow the ssl-mode option of the MySQL clients.
+type SSLMode string
+
+const (
+	// SSLModeDisabled does not use TLS.
+	SSLModeDisabled SSLMode = "DISABLED"
+	// SSLModePreferred uses TLS when the server supports it, and falls back
+	// to an unencrypted connection otherwise. The certificate of the server
+	// is not verified.
+	SSLModePreferred SSLMode = "PREFERRED"
+	// SSLModeRequired requires TLS. The certificate of the server is not
+	// verified, unless a CA certificate is configured, in which case it is
+	// the same as SSLModeVerifyCA.
+	SSLModeRequired SSLMode = "REQUIRED"
+	// SSLModeVerifyCA requires TLS and verifies the certificate of the server
+	// using the configured CA certificate, or the system's CAs.
+	SSLModeVerifyCA SSLMode = "VERIFY_CA"
+	// SSLModeVerifyIdentity is like SSLModeVerifyCA, and also verifies that
+	// the certificate of the server matches the host name.
+	SSLModeVerifyIdentity SSLMode = "VERIFY_IDENTITY"
+)
+
+var sslModes = []SSLMode{
+	SSLModeDisabled, SSLModePreferred, SSLModeRequired, SSLModeVerifyCA, SSLModeVerifyIdentity,
+}
+
+// ParseSSLMode returns the SSLMode named s, ignoring case.
+func ParseSSLMode(s string) (SSLMode, error) {
+	m := SSLMode(strings.ToUpper(s))
+	for _, v := range sslModes {
+		if m == v {
+			return m, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported SSL mode '%s'", s)
+}
+
+// EffectiveSSLMode returns the SSL mode used when connecting with cfg. When
+// SSLMode is not set, UseTLS decides: when false, TLS is disabled unless
+// a TLS configuration is provided using TLSConfig or GetTLSConfig; when true,
+// TLS is required and the host name is verified when a CA certificate is
+// configured.
+func (cfg *ConnectConfig) EffectiveSSLMode() SSLMode {
+	switch {
+	case cfg.SSLMode != "":
+		return cfg.SSLMode
+	case cfg.TLSConfig != nil || cfg.GetTLSConfig != nil:
+		return SSLModeRequired
+	case !cfg.UseTLS:
+		return SSLModeDisabled
+	case cfg.TLSServerCACertPath != "":
+		return SSLModeVerifyIdentity
+	default:
+		return SSLModeRequired
+	}
+}
+
+// tlsVersions maps the names of TLS versions as used by MySQL.
+var tlsVersions = map[string]uint16{
+	"TLSv1.2": tls.VersionTLS12,
+	"TLSv1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion returns the TLS version with given name, for example "TLSv1.3".
+// Versions before TLS 1.2 are not supported.
+func ParseTLSVersion(name string) (uint16, error) {
+	for n, v := range tlsVersions {
+		if strings.EqualFold(n, name) {
+			return v, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unsupported TLS version '%s'", name)
+}
+
+// TLSVersionName returns the name of TLS version v as used by MySQL,
+// for example "TLSv1.3".
+func TLSVersionName(v uint16) string {
+	for n, version := range tlsVersions {
+		if v == version {
+			return n
+		}
+	}
+
+	return fmt.Sprintf("0x%04X", v)
+}
+
+// ParseTLSCipherSuite returns the ID of the cipher suite with given IANA name,
+// for example "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". Insecure cipher suites
+// are not supported.
+func ParseTLSCipherSuite(name string) (uint16, error) {
+	for _, cs := range tls.CipherSuites() {
+		if cs.Name == name {
+			return cs.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unsupported TLS cipher suite '%s'", name)
+}
+
+// tlsConfig returns the TLS configuration for connecting using mode. The
+// configuration provided using GetTLSConfig or TLSConfig is used as is,
+// with ServerName set when not provided. Otherwise, the configuration is
+// built using the TLS fields of the session's configuration.
+func (ses *Session) tlsConfig(ctx context.Context, mode SSLMode) (*tls.Config, error) {
+	var provided *tls.Config
+	switch {
+	case ses.config.GetTLSConfig != nil:
+		c, err := ses.config.GetTLSConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("getting TLS configuration (%w)", err)
+		}
+		provided = c
+	case ses.config.TLSConfig != nil:
+		provided = ses.config.TLSConfig
+	}
+
+	if provided != nil {
+		c := provided.Clone()
+		if c.ServerName == "" {
+			c.ServerName = ses.serverHostname()
+		}
+		return c, nil
+	}
+
+	var rootCAs *x509.CertPool // nil means system's CAs
+	if ses.config.TLSServerCACertPath != "" {
+		var err error
+		rootCAs, err = network.CACertPoolFromFile(ses.config.TLSServerCACertPath, ses.config.TLSUseSystemCAs)
+		if err != nil {
+			return nil, err
+		}
+
+		if mode == SSLModeRequired {
+			mode = SSLModeVerifyCA
+		}
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   ses.config.TLSMinVersion,
+		MaxVersion:   ses.config.TLSMaxVersion,
+		CipherSuites: ses.config.TLSCipherSuites,
+	}
+
+	switch mode {
+	case SSLModeVerifyIdentity:
+		hostname := ses.serverHostname()
+		if hostname == "" {
+			return nil, fmt.Errorf("SSL mode %s requires a host name", mode)
+		}
+		tlsConfig.RootCAs = rootCAs
+		tlsConfig.ServerName = hostname
+	case SSLModeVerifyCA:
+		tlsConfig.InsecureSkipVerify = true // chain is verified, host name is not
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			_, err := verifyCertificateChain(cs, rootCAs)
+			return err
+		}
+	default:
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if err := ses.addClientCertificate(tlsConfig); err != nil {
+		return nil, err
+	}
+
+	if err := ses.addRevocationCheck(tlsConfig, rootCAs); err != nil {
+		return nil, err
+	}
+
+	return tlsConfig, nil
+}
+
+// addClientCertificate adds the configured client certificate and key to
+// tlsConfig. Both or none must be configured.
+func (ses *Session) addClientCertificate(tlsConfig *tls.Config) error {
+	certPath, keyPath := ses.config.TLSClientCertPath, ses.config.TLSClientKeyPath
+
+	switch {
+	case certPath == "" && keyPath == "":
+		return nil
+	case certPath == "" || keyPath == "":
+		return fmt.Errorf("TLS client certificate and key must both be configured")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return fmt.Errorf("loading TLS client certificate (%w)", err)
+	}
+
+	tlsConfig.Certificates = []tls.Certificate{cert}
+	return nil
+}
+
+// addRevocationCheck makes tlsConfig check the certificates of the server
+// against the configured certificate revocation list (CRL). The chain of
+// certificates is verified using rootCAs when crypto/tls did not, so that the
+// signature of the CRL is checked using an issuer which is trusted.
+func (ses *Session) addRevocationCheck(tlsConfig *tls.Config, rootCAs *x509.CertPool) error {
+	if ses.config.TLSCRLPath == "" {
+		return nil
+	}
+
+	crl, err := network.RevocationListFromFile(ses.config.TLSCRLPath)
+	if err != nil {
+		return err
+	}
+
+	verify := tlsConfig.VerifyConnection
+	tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if verify != nil {
+			if err := verify(cs); err != nil {
+				return err
+			}
+		}
+
+		chains := cs.VerifiedChains
+		if len(chains) == 0 {
+			var err error
+			if chains, err = verifyCertificateChain(cs, rootCAs); err != nil {
+				return err
+			}
+		}
+
+		return network.CheckRevoked(chains, crl, time.Now())
+	}
+
+	return nil
+}
+
+// verifyCertificateChain verifies the certificates presented by the server
+// using roots, but not the host name, and returns the verified chains.
+func verifyCertificateChain(cs tls.ConnectionState, roots *x509.CertPool) ([][]*x509.Certificate, error) {
+	if len(cs.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("server did not present a certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verifying server certificate (%w)", err)
+	}
+
+	return chains, nil
+}
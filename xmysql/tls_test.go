@@ -0,0 +1,98 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql_test
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/xmysql"
+)
+
+func TestParseSSLMode(t *testing.T) {
+	t.Run("valid modes", func(t *testing.T) {
+		var cases = map[string]xmysql.SSLMode{
+			"disabled":        xmysql.SSLModeDisabled,
+			"Preferred":       xmysql.SSLModePreferred,
+			"REQUIRED":        xmysql.SSLModeRequired,
+			"verify_ca":       xmysql.SSLModeVerifyCA,
+			"VERIFY_IDENTITY": xmysql.SSLModeVerifyIdentity,
+		}
+
+		for s, exp := range cases {
+			t.Run(s, func(t *testing.T) {
+				mode, err := xmysql.ParseSSLMode(s)
+				xt.OK(t, err)
+				xt.Eq(t, exp, mode)
+			})
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := xmysql.ParseSSLMode("sometimes")
+		xt.KO(t, err)
+		xt.Eq(t, "unsupported SSL mode 'sometimes'", err.Error())
+	})
+}
+
+func TestConnectConfig_EffectiveSSLMode(t *testing.T) {
+	var cases = []struct {
+		name   string
+		config *xmysql.ConnectConfig
+		exp    xmysql.SSLMode
+	}{
+		{
+			name:   "no TLS",
+			config: &xmysql.ConnectConfig{},
+			exp:    xmysql.SSLModeDisabled,
+		},
+		{
+			name:   "use TLS",
+			config: &xmysql.ConnectConfig{UseTLS: true},
+			exp:    xmysql.SSLModeRequired,
+		},
+		{
+			name:   "use TLS with CA certificate",
+			config: &xmysql.ConnectConfig{UseTLS: true, TLSServerCACertPath: "ca.pem"},
+			exp:    xmysql.SSLModeVerifyIdentity,
+		},
+		{
+			name:   "SSL mode takes precedence",
+			config: &xmysql.ConnectConfig{UseTLS: false, SSLMode: xmysql.SSLModePreferred},
+			exp:    xmysql.SSLModePreferred,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			xt.Eq(t, c.exp, c.config.EffectiveSSLMode())
+		})
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	t.Run("valid versions", func(t *testing.T) {
+		v, err := xmysql.ParseTLSVersion("tlsv1.3")
+		xt.OK(t, err)
+		xt.Eq(t, uint16(tls.VersionTLS13), v)
+		xt.Eq(t, "TLSv1.3", xmysql.TLSVersionName(v))
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := xmysql.ParseTLSVersion("TLSv1.1")
+		xt.KO(t, err)
+		xt.Eq(t, "unsupported TLS version 'TLSv1.1'", err.Error())
+	})
+}
+
+func TestParseTLSCipherSuite(t *testing.T) {
+	id, err := xmysql.ParseTLSCipherSuite("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+	xt.OK(t, err)
+	xt.Eq(t, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, id)
+
+	_, err = xmysql.ParseTLSCipherSuite("ECDHE-RSA-AES128-GCM-SHA256")
+	xt.KO(t, err)
+	xt.Eq(t, "unsupported TLS cipher suite 'ECDHE-RSA-AES128-GCM-SHA256'", err.Error())
+}
@@ -0,0 +1,629 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xmysql
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golistic/xgo/xstrings"
+)
+
+// URIScheme is the scheme of URIs connecting using the MySQL X Protocol.
+const URIScheme = "mysqlx"
+
+// uriOptionsUnsupported are options defined by the X DevAPI which are not
+// (yet) supported.
+var uriOptionsUnsupported = []string{
+	"ssl-capath", "ssl-crlpath", "tls-version",
+}
+
+// ParseURI parses a URI as used by MySQL Shell and the other X DevAPI connectors,
+// and returns the configuration. The URI has the form:
+//
+//	mysqlx://[user[:password]@]host[:port][/schema][?option=value[&option=value]]
+//
+// The host can be an IPv6 address within brackets, or the path of a Unix socket
+// either percent-encoded or within parentheses, for example,
+// `mysqlx://scott@(/tmp/mysqlx.sock)/test`. Multiple hosts, stored as
+// ConnectConfig.Hosts, are given within brackets, optionally with their
+// priority, for example, `mysqlx://scott@[db1:33060,db2:33060]/test` or
+// `mysqlx://scott@[(address=db1,priority=100),(address=db2,priority=90)]/test`.
+//
+// Supported options are ssl-mode, ssl-ca, ssl-cert, ssl-key, ssl-crl,
+// tls-versions, tls-ciphersuites, auth, connect-timeout (in milliseconds),
+// connection-attributes, compression, and compression-algorithms. Lists, such as tls-versions, are given within brackets,
+// for example, `tls-versions=[TLSv1.2,TLSv1.3]` or `connection-attributes=[app=shop]`. Following the X DevAPI, the SSL mode is REQUIRED when
+// not specified. Options specific to this package are time-zone, collation,
+// read-timeout and write-timeout (in milliseconds), max-allowed-packet,
+// use-null-of, expand-slices, and randomize-hosts.
+func ParseURI(uri string) (*ConnectConfig, error) {
+	errMsg := "invalid URI (%w)"
+
+	rest, ok := strings.CutPrefix(uri, URIScheme+"://")
+	if !ok {
+		return nil, fmt.Errorf(errMsg, fmt.Errorf("scheme must be %s", URIScheme))
+	}
+
+	cfg := &ConnectConfig{
+		UseTLS:     true,
+		SSLMode:    SSLModeRequired,
+		AuthMethod: AuthMethodAuto,
+	}
+
+	var query string
+	rest, query, _ = strings.Cut(rest, "?")
+
+	if i := strings.LastIndex(rest, "@"); i > -1 {
+		if err := cfg.parseUserInfo(rest[:i]); err != nil {
+			return nil, fmt.Errorf(errMsg, err)
+		}
+		rest = rest[i+1:]
+	}
+
+	rest, err := cfg.parseHost(rest)
+	if err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+
+	if rest != "" {
+		if rest[0] != '/' {
+			return nil, fmt.Errorf(errMsg, fmt.Errorf("unexpected '%s' after host", rest))
+		}
+		if cfg.Schema, err = url.PathUnescape(strings.TrimSuffix(rest[1:], "/")); err != nil {
+			return nil, fmt.Errorf(errMsg, fmt.Errorf("schema not valid"))
+		}
+	}
+
+	if err := cfg.parseURIOptions(query); err != nil {
+		return nil, fmt.Errorf(errMsg, err)
+	}
+
+	return cfg, nil
+}
+
+// URI returns cfg as mysqlx:// URI which can be parsed using ParseURI. Note that
+// the URI includes the password when it is set.
+func (cfg *ConnectConfig) URI() string {
+	var b strings.Builder
+	b.WriteString(URIScheme + "://")
+
+	if cfg.Username != "" || cfg.Password != nil {
+		var userInfo *url.Userinfo
+		if cfg.Password != nil {
+			userInfo = url.UserPassword(cfg.Username, *cfg.Password)
+		} else {
+			userInfo = url.User(cfg.Username)
+		}
+		b.WriteString(userInfo.String() + "@")
+	}
+
+	switch {
+	case len(cfg.Hosts) > 0:
+		b.WriteString("[" + FormatHosts(cfg.Hosts) + "]")
+	case cfg.UnixSockAddr != "":
+		b.WriteString("(" + cfg.UnixSockAddr + ")")
+	default:
+		b.WriteString(cfg.Address)
+	}
+
+	if cfg.Schema != "" {
+		b.WriteString("/" + url.PathEscape(cfg.Schema))
+	}
+
+	options := url.Values{}
+	if mode := cfg.EffectiveSSLMode(); mode != SSLModeRequired {
+		options.Set("ssl-mode", string(mode))
+	}
+	if cfg.TLSServerCACertPath != "" {
+		options.Set("ssl-ca", cfg.TLSServerCACertPath)
+	}
+	if cfg.TLSClientCertPath != "" {
+		options.Set("ssl-cert", cfg.TLSClientCertPath)
+	}
+	if cfg.TLSClientKeyPath != "" {
+		options.Set("ssl-key", cfg.TLSClientKeyPath)
+	}
+	if cfg.TLSCRLPath != "" {
+		options.Set("ssl-crl", cfg.TLSCRLPath)
+	}
+	if cfg.TLSMinVersion != 0 || cfg.TLSMaxVersion != 0 {
+		minVersion, maxVersion := cfg.TLSMinVersion, cfg.TLSMaxVersion
+		if minVersion == 0 {
+			minVersion = tls.VersionTLS12
+		}
+		if maxVersion == 0 {
+			maxVersion = tls.VersionTLS13
+		}
+		var versions []string
+		for v := minVersion; v <= maxVersion; v++ {
+			versions = append(versions, TLSVersionName(v))
+		}
+		options.Set("tls-versions", "["+strings.Join(versions, ",")+"]")
+	}
+	if len(cfg.TLSCipherSuites) > 0 {
+		var names []string
+		for _, id := range cfg.TLSCipherSuites {
+			names = append(names, tls.CipherSuiteName(id))
+		}
+		options.Set("tls-ciphersuites", "["+strings.Join(names, ",")+"]")
+	}
+	if cfg.AuthMethod != "" && cfg.AuthMethod != AuthMethodAuto {
+		options.Set("auth", string(cfg.AuthMethod))
+	}
+	if cfg.ConnectTimeout > 0 {
+		options.Set("connect-timeout", strconv.FormatInt(cfg.ConnectTimeout.Milliseconds(), 10))
+	}
+	if cfg.DisableConnectionAttributes {
+		options.Set("connection-attributes", "false")
+	} else if len(cfg.ConnectionAttributes) > 0 {
+		var attrs []string
+		for k, v := range cfg.ConnectionAttributes {
+			attrs = append(attrs, k+"="+v)
+		}
+		sort.Strings(attrs)
+		options.Set("connection-attributes", "["+strings.Join(attrs, ",")+"]")
+	}
+	if cfg.Compression != "" {
+		options.Set("compression", string(cfg.Compression))
+	}
+	if len(cfg.CompressionAlgorithms) > 0 {
+		options.Set("compression-algorithms", "["+strings.Join(cfg.CompressionAlgorithms, ",")+"]")
+	}
+	if cfg.TimeZoneName != "" {
+		options.Set("time-zone", cfg.TimeZoneName)
+	}
+	if cfg.Collation != "" {
+		options.Set("collation", cfg.Collation)
+	}
+	if cfg.ReadTimeout > 0 {
+		options.Set("read-timeout", strconv.FormatInt(cfg.ReadTimeout.Milliseconds(), 10))
+	}
+	if cfg.WriteTimeout > 0 {
+		options.Set("write-timeout", strconv.FormatInt(cfg.WriteTimeout.Milliseconds(), 10))
+	}
+	if cfg.MaxAllowedPacket > 0 {
+		options.Set("max-allowed-packet", strconv.Itoa(cfg.MaxAllowedPacket))
+	}
+	if cfg.UseNullOf {
+		options.Set("use-null-of", "true")
+	}
+	if cfg.ExpandSlices {
+		options.Set("expand-slices", "true")
+	}
+	if cfg.RandomizeHosts {
+		options.Set("randomize-hosts", "true")
+	}
+
+	if len(options) > 0 {
+		b.WriteString("?" + options.Encode())
+	}
+
+	return b.String()
+}
+
+func (cfg *ConnectConfig) parseUserInfo(s string) error {
+	user, password, hasPassword := strings.Cut(s, ":")
+
+	var err error
+	if cfg.Username, err = url.PathUnescape(user); err != nil {
+		return fmt.Errorf("user not valid")
+	}
+
+	if hasPassword {
+		p, err := url.PathUnescape(password)
+		if err != nil {
+			return fmt.Errorf("password not valid")
+		}
+		cfg.SetPassword(p)
+	}
+
+	return nil
+}
+
+// parseHost parses the host, including the optional port, at the start of s
+// and returns what follows.
+func (cfg *ConnectConfig) parseHost(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "("):
+		end := strings.Index(s, ")")
+		if end == -1 {
+			return "", fmt.Errorf("socket path not closed")
+		}
+		cfg.UnixSockAddr = s[1:end]
+		return s[end+1:], nil
+
+	case strings.HasPrefix(s, "["):
+		end := closingBracket(s)
+		if end == -1 {
+			return "", fmt.Errorf("host not closed")
+		}
+		if strings.ContainsAny(s[1:end], ",([") {
+			hosts, err := ParseHosts(s[1:end])
+			if err != nil {
+				return "", err
+			}
+			cfg.Hosts = hosts
+			return s[end+1:], nil
+		}
+		port, rest, found := strings.Cut(s[end+1:], "/")
+		if found {
+			rest = "/" + rest
+		}
+		if err := checkURIPort(port); err != nil {
+			return "", err
+		}
+		cfg.Address = s[:end+1] + port
+		return rest, nil
+	}
+
+	host, rest, found := strings.Cut(s, "/")
+	if found {
+		rest = "/" + rest
+	}
+
+	unescaped, err := url.PathUnescape(host)
+	if err != nil {
+		return "", fmt.Errorf("host not valid")
+	}
+
+	if strings.HasPrefix(unescaped, "/") || strings.HasPrefix(unescaped, ".") {
+		cfg.UnixSockAddr = unescaped
+		return rest, nil
+	}
+
+	if i := strings.LastIndex(host, ":"); i > -1 {
+		if err := checkURIPort(host[i:]); err != nil {
+			return "", err
+		}
+	}
+
+	cfg.Address = host
+	return rest, nil
+}
+
+// ParseHosts parses the list of hosts s, which are either all given as
+// host[:port], or all as (address=host[:port],priority=N), separated by
+// commas. This is the form used within the brackets of mysqlx:// URIs, for
+// example, `db1:33060,db2:33060` or
+// `(address=db1,priority=100),(address=db2,priority=90)`.
+func ParseHosts(s string) ([]Host, error) {
+	var hosts []Host
+	var withPriority int
+
+	for _, item := range splitTopLevel(s) {
+		var h Host
+
+		if strings.HasPrefix(item, "(") {
+			if !strings.HasSuffix(item, ")") {
+				return nil, fmt.Errorf("host not closed")
+			}
+			for _, kv := range splitTopLevel(item[1 : len(item)-1]) {
+				k, v, _ := strings.Cut(kv, "=")
+				switch strings.ToLower(strings.TrimSpace(k)) {
+				case "address":
+					h.Address = strings.TrimSpace(v)
+				case "priority":
+					n, err := strconv.Atoi(strings.TrimSpace(v))
+					if err != nil || n < 0 || n > MaxHostPriority {
+						return nil, fmt.Errorf("priority not valid (was %s)", v)
+					}
+					h.Priority = n
+					withPriority++
+				default:
+					return nil, fmt.Errorf("unknown host attribute '%s'", k)
+				}
+			}
+		} else {
+			h.Address = item
+		}
+
+		if h.Address == "" {
+			return nil, fmt.Errorf("host address missing")
+		}
+		if err := checkHostPort(h.Address); err != nil {
+			return nil, err
+		}
+
+		hosts = append(hosts, h)
+	}
+
+	if withPriority > 0 && withPriority != len(hosts) {
+		return nil, fmt.Errorf("priority must be given for all hosts or none")
+	}
+
+	return hosts, nil
+}
+
+// FormatHosts returns hosts in the form read by ParseHosts. Priorities are
+// only included when at least one host has one.
+func FormatHosts(hosts []Host) string {
+	withPriority := false
+	for _, h := range hosts {
+		if h.Priority != 0 {
+			withPriority = true
+			break
+		}
+	}
+
+	items := make([]string, len(hosts))
+	for i, h := range hosts {
+		if withPriority {
+			items[i] = fmt.Sprintf("(address=%s,priority=%d)", h.Address, h.Priority)
+		} else {
+			items[i] = h.Address
+		}
+	}
+
+	return strings.Join(items, ",")
+}
+
+// checkHostPort checks the port of addr, which can be an IPv6 address
+// within brackets.
+func checkHostPort(addr string) error {
+	if strings.HasPrefix(addr, "[") {
+		end := strings.Index(addr, "]")
+		if end == -1 {
+			return fmt.Errorf("host not closed")
+		}
+		return checkURIPort(addr[end+1:])
+	}
+
+	if i := strings.LastIndex(addr, ":"); i > -1 {
+		return checkURIPort(addr[i:])
+	}
+
+	return nil
+}
+
+// closingBracket returns the index of the bracket closing the one at the
+// start of s, or -1 when it is not closed.
+func closingBracket(s string) int {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// splitTopLevel splits s at the commas which are not within brackets
+// or parentheses.
+func splitTopLevel(s string) []string {
+	var items []string
+	depth, start := 0, 0
+
+	for i, c := range s {
+		switch c {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	return append(items, strings.TrimSpace(s[start:]))
+}
+
+// checkURIPort checks s which is empty, or a colon followed by the port number.
+func checkURIPort(s string) error {
+	if s == "" {
+		return nil
+	}
+
+	port, ok := strings.CutPrefix(s, ":")
+	if n, err := strconv.ParseUint(port, 10, 16); !ok || err != nil || n == 0 {
+		return fmt.Errorf("port not valid (was %s)", s)
+	}
+
+	return nil
+}
+
+func (cfg *ConnectConfig) parseURIOptions(query string) error {
+	if query == "" {
+		return nil
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf("could not parse options")
+	}
+
+	var names []string
+	options := map[string]string{}
+	for name, v := range values {
+		key := strings.ToLower(name)
+		if _, have := options[key]; have || len(v) > 1 {
+			return fmt.Errorf("option %s given more than once", key)
+		}
+		options[key] = v[0]
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	invalid := func(name, value string) error {
+		return fmt.Errorf("invalid value for option %s (was %s)", name, value)
+	}
+
+	parseMilliseconds := func(name, value string) (time.Duration, error) {
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return 0, invalid(name, value)
+		}
+		return time.Duration(n) * time.Millisecond, nil
+	}
+
+	parseBool := func(name, value string) (bool, error) {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, invalid(name, value)
+		}
+		return b, nil
+	}
+
+	for _, name := range names {
+		value := options[name]
+
+		switch name {
+		case "ssl-mode":
+			if cfg.SSLMode, err = ParseSSLMode(value); err != nil {
+				return invalid(name, value)
+			}
+			cfg.UseTLS = cfg.SSLMode != SSLModeDisabled
+		case "ssl-ca":
+			cfg.TLSServerCACertPath = value
+		case "ssl-cert":
+			cfg.TLSClientCertPath = value
+		case "ssl-key":
+			cfg.TLSClientKeyPath = value
+		case "ssl-crl":
+			cfg.TLSCRLPath = value
+		case "tls-versions":
+			cfg.TLSMinVersion, cfg.TLSMaxVersion = 0, 0
+			for _, n := range uriList(value) {
+				v, err := ParseTLSVersion(n)
+				if err != nil {
+					return invalid(name, value)
+				}
+				if cfg.TLSMinVersion == 0 || v < cfg.TLSMinVersion {
+					cfg.TLSMinVersion = v
+				}
+				if v > cfg.TLSMaxVersion {
+					cfg.TLSMaxVersion = v
+				}
+			}
+			if cfg.TLSMinVersion == 0 {
+				return invalid(name, value)
+			}
+		case "tls-ciphersuites":
+			cfg.TLSCipherSuites = nil
+			for _, n := range uriList(value) {
+				id, err := ParseTLSCipherSuite(n)
+				if err != nil {
+					return invalid(name, value)
+				}
+				cfg.TLSCipherSuites = append(cfg.TLSCipherSuites, id)
+			}
+		case "auth":
+			cfg.AuthMethod = AuthMethodType(strings.ToUpper(value))
+			if !SupportedAuthMethods().Has(cfg.AuthMethod) {
+				return invalid(name, value)
+			}
+		case "connect-timeout":
+			if cfg.ConnectTimeout, err = parseMilliseconds(name, value); err != nil {
+				return err
+			}
+		case "connection-attributes":
+			cfg.ConnectionAttributes, cfg.DisableConnectionAttributes = nil, false
+			switch strings.ToLower(value) {
+			case "true":
+			case "false":
+				cfg.DisableConnectionAttributes = true
+			default:
+				if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+					return invalid(name, value)
+				}
+				cfg.ConnectionAttributes = map[string]string{}
+				for _, attr := range uriList(value) {
+					k, v, _ := strings.Cut(attr, "=")
+					cfg.ConnectionAttributes[strings.TrimSpace(k)] = strings.TrimSpace(v)
+				}
+				if len(cfg.ConnectionAttributes) == 0 {
+					cfg.ConnectionAttributes = nil
+				}
+				if err := checkConnectionAttributes(cfg.ConnectionAttributes); err != nil {
+					return invalid(name, value)
+				}
+			}
+		case "compression":
+			if cfg.Compression, err = ParseCompressionMode(value); err != nil {
+				return invalid(name, value)
+			}
+		case "compression-algorithms":
+			cfg.CompressionAlgorithms = nil
+			for _, n := range uriList(value) {
+				a, err := ParseCompressionAlgorithm(n)
+				if err != nil {
+					return invalid(name, value)
+				}
+				cfg.CompressionAlgorithms = append(cfg.CompressionAlgorithms, a)
+			}
+		case "time-zone":
+			if _, err := time.LoadLocation(value); err != nil {
+				return invalid(name, value)
+			}
+			cfg.TimeZoneName = value
+		case "collation":
+			if !IsSupportedCollation(value) {
+				return invalid(name, value)
+			}
+			cfg.Collation = value
+		case "read-timeout":
+			if cfg.ReadTimeout, err = parseMilliseconds(name, value); err != nil {
+				return err
+			}
+		case "write-timeout":
+			if cfg.WriteTimeout, err = parseMilliseconds(name, value); err != nil {
+				return err
+			}
+		case "max-allowed-packet":
+			n, err := strconv.ParseUint(value, 10, 31)
+			if err != nil {
+				return invalid(name, value)
+			}
+			cfg.MaxAllowedPacket = int(n)
+		case "use-null-of":
+			if cfg.UseNullOf, err = parseBool(name, value); err != nil {
+				return err
+			}
+		case "expand-slices":
+			if cfg.ExpandSlices, err = parseBool(name, value); err != nil {
+				return err
+			}
+		case "randomize-hosts":
+			if cfg.RandomizeHosts, err = parseBool(name, value); err != nil {
+				return err
+			}
+		default:
+			if xstrings.SliceHas(uriOptionsUnsupported, name) {
+				return fmt.Errorf("option %s not supported", name)
+			}
+			return fmt.Errorf("unknown option '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// uriList returns the elements of a list given as option value, for
+// example, `[a,b]`. The brackets are optional.
+func uriList(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(value), "["), "]")
+
+	var list []string
+	for _, e := range strings.Split(value, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+
+	return list
+}
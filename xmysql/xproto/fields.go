@@ -3,6 +3,8 @@
 package xproto
 
 import (
+	"sort"
+
 	"github.com/golistic/xgo/xstrings"
 
 	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxdatatypes"
@@ -21,15 +23,38 @@ func ObjectField[T ~string | ~bool | ~[]*mysqlxdatatypes.Object_ObjectField](key
 	case bool:
 		f.Value = Bool(v)
 	case []*mysqlxdatatypes.Object_ObjectField:
-		f.Value = &mysqlxdatatypes.Any{
-			Type: mysqlxdatatypes.Any_OBJECT.Enum(),
-			Obj: &mysqlxdatatypes.Object{
-				Fld: v,
-			},
-		}
+		f.Value = Object(v...)
 	default:
 		panic("unsupported value type")
 	}
 
 	return f
 }
+
+// Object returns an object-valued Any containing fields, for example, to
+// set a capability like session_connect_attrs.
+func Object(fields ...*mysqlxdatatypes.Object_ObjectField) *mysqlxdatatypes.Any {
+	return &mysqlxdatatypes.Any{
+		Type: mysqlxdatatypes.Any_OBJECT.Enum(),
+		Obj: &mysqlxdatatypes.Object{
+			Fld: fields,
+		},
+	}
+}
+
+// StringObject returns an object-valued Any with a string field for each
+// key of m. Fields are sorted by key.
+func StringObject(m map[string]string) *mysqlxdatatypes.Any {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make(ObjectFields, len(keys))
+	for i, k := range keys {
+		fields[i] = ObjectField(k, m[k])
+	}
+
+	return Object(fields...)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2023, Geert JM Vanderkelen
+
+package xproto_test
+
+import (
+	"testing"
+
+	"github.com/golistic/xgo/xt"
+
+	"github.com/golistic/pxmysql/internal/mysqlx/mysqlxdatatypes"
+	"github.com/golistic/pxmysql/xmysql/xproto"
+)
+
+func TestStringObject(t *testing.T) {
+	t.Run("fields sorted by key", func(t *testing.T) {
+		obj := xproto.StringObject(map[string]string{
+			"program_name": "gopher",
+			"_pid":         "42",
+		})
+
+		xt.Eq(t, mysqlxdatatypes.Any_OBJECT, obj.GetType())
+		xt.Eq(t, 2, len(obj.GetObj().GetFld()))
+
+		for i, exp := range [][2]string{{"_pid", "42"}, {"program_name", "gopher"}} {
+			f := obj.GetObj().GetFld()[i]
+			xt.Eq(t, exp[0], f.GetKey())
+			xt.Eq(t, exp[1], string(f.GetValue().GetScalar().GetVString().GetValue()))
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		obj := xproto.StringObject(nil)
+		xt.Eq(t, mysqlxdatatypes.Any_OBJECT, obj.GetType())
+		xt.Eq(t, 0, len(obj.GetObj().GetFld()))
+	})
+}